@@ -8,15 +8,24 @@ import (
 	"net/http"
 	"time"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"encoding/json"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	"github.com/redis/go-redis/v9"
 	"backend/pkg/aws_utils"
+	"backend/pkg/export"
+	"backend/pkg/ocr"
+	"backend/pkg/pdf"
+	"backend/pkg/preprocess"
 	"backend/pkg/rabbitmq"
 	"backend/pkg/redis"
+	"backend/pkg/translation"
 	"backend/pkg/utils"
 	"flag"
 )
@@ -100,8 +109,8 @@ func main() {
 	// Config CORS middleware
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"}, // Adjust this to match your frontend's origin
-		AllowMethods:     []string{"GET", "POST"},
-		AllowHeaders:     []string{"Origin", "Content-Type"},
+		AllowMethods:     []string{"GET", "POST", "DELETE"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "X-Tenant-ID"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
@@ -119,24 +128,133 @@ func main() {
 			c.String(http.StatusBadRequest, fmt.Sprintf("get file err: %s", err.Error()))
 			return
 		}
+
+		// target_lang may be repeated or comma separated, target_langs is accepted too
+		targetLangs := translation.NormalizeTargetLanguages(append(c.PostFormArray("target_lang"), c.PostForm("target_langs")))
+		if len(targetLangs) > translation.MaxTargetLanguages {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d target languages are allowed", translation.MaxTargetLanguages)})
+			return
+		}
+		sourceLang, targetLang := translation.NormalizeLanguages(c.PostForm("source_lang"), targetLangs[0])
+		for _, lang := range targetLangs {
+			err = translation.ValidateLanguages(sourceLang, lang)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		multiLang := len(targetLangs) > 1
+
+		outputLayout := c.DefaultPostForm("output", pdf.LayoutTranslated)
+		if err := pdf.ValidateLayout(outputLayout); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Tesseract settings, e.g. ocr_lang=vie+eng&ocr_psm=6&ocr_variables={"tessedit_char_whitelist":"0123456789"}
+		ocrConfig, err := models.ParseOCRConfig(c.PostForm("ocr_lang"), c.PostForm("ocr_psm"), c.PostForm("ocr_oem"), c.PostForm("ocr_variables"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// the languages installed on the OCR workers, none until one has started
+		installedLangs, err := redis_utils.OCRLanguages(redisClient, redisCtx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(installedLangs) > 0 {
+			if err := ocrConfig.CheckInstalled(installedLangs); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		// image cleanup before OCR, a preset (scan, photo, fax) or steps, e.g. preprocess=grayscale,deskew,binarize
+		preprocessSteps, err := preprocess.Parse(c.PostForm("preprocess"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// regions=[{"name":"address","x":120,"y":80,"width":600,"height":200}] recognizes only
+		// those rectangles, in pixels or, with "unit":"relative", fractions of the image
+		regions, err := ocr.ParseRegions(c.PostForm("regions"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// highlight_low_confidence=true marks unreliable OCR words in the PDF
+		var highlightBelow float64
+		if highlight, _ := strconv.ParseBool(c.PostForm("highlight_low_confidence")); highlight {
+			highlightBelow = ocr.WordConfidenceThreshold()
+		}
+
 		// compute the hash key for the file
 		hash, err := utils.GenerateHashFromFormFile(file)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate hash"})
 			return
 		}
+		tenantID := getTenantID(c)
+		glossaryID := c.PostForm("glossary_id")
+		if glossaryID != "" {
+			glossary, err := redis_utils.GetGlossary(redisClient, redisCtx, tenantID, glossaryID)
+			if err == redis_utils.ErrGlossaryNotFound {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown glossary_id"})
+				return
+			} else if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load glossary"})
+				return
+			}
+			// the glossary is applied to the target languages it matches
+			matches := false
+			for _, lang := range targetLangs {
+				matches = matches || translation.GlossaryMatches(glossary, sourceLang, lang)
+			}
+			if !matches {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Glossary does not match the requested languages"})
+				return
+			}
+		}
+
+		// multi-page TIFF scans are recognized page by page
+		pages, err := utils.CountPagesFromFormFile(file)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// regions are cropped from the decoded image, TIFF pages are only read by Tesseract
+		if len(regions) > 0 {
+			isTIFF, err := utils.IsTIFFFromFormFile(file)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+				return
+			}
+			if isTIFF {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "regions are not supported for TIFF files"})
+				return
+			}
+		}
+
+		// the same file translated to another language is a different job
+		jobID := utils.GenerateJobID(hash, sourceLang, strings.Join(targetLangs, ","), tenantID, glossaryID, outputLayout, ocrConfig.Key(), strconv.FormatFloat(highlightBelow, 'f', -1, 64), strings.Join(preprocessSteps, ","), models.RegionsKey(regions))
 
 		// check if the file content is already processed?
 		
-		status, err := redisClient.HGet(redisCtx, hash, "status").Result()
+		status, err := redisClient.HGet(redisCtx, jobID, "status").Result()
 
 		if err == nil && status != "" {
 			// Respond with a success message
-			c.JSON(200, gin.H{"message": "Job submitted", "jobID": hash})
+			c.JSON(200, gin.H{"message": "Job submitted", "jobID": jobID, "source_lang": sourceLang, "target_lang": targetLang, "target_langs": targetLangs})
 			return
 		}
 
 		var imagePath string
+		PDFUploadURLs := map[string]string{}
+		ResultUploadURLs := map[string]string{}
 
 		if storage_type == "local" {
 			// save the file to local for further processing
@@ -156,9 +274,25 @@ func main() {
 				return
 			}
 
-			// Generate presign URL for translation worker to upload the pdf
-			out_key := "output/" + hash + ".pdf"
-			PDFUploadURL, err = aws_utils.GenerateUploadURL(s3_bucket_name, out_key, 15*time.Minute)
+			// Generate presign URLs for translation workers to upload the pdf of each language
+			for _, lang := range targetLangs {
+				out_key := "output/" + models.OutputName(jobID, lang, multiLang) + ".pdf"
+				PDFUploadURLs[lang], err = aws_utils.GenerateUploadURL(s3_bucket_name, out_key, 15*time.Minute)
+				if err != nil {
+					c.String(http.StatusInternalServerError, fmt.Sprintf("failed to generate upload pre-signed URL: %s", err.Error()))
+					return
+				}
+			}
+			PDFUploadURL = PDFUploadURLs[targetLang]
+
+			// and for the OCR worker to upload the hOCR and ALTO results
+			for _, format := range export.OCRFormats {
+				ResultUploadURLs[format], err = aws_utils.GenerateUploadURL(s3_bucket_name, "output/"+jobID+export.Extension(format), 15*time.Minute)
+				if err != nil {
+					c.String(http.StatusInternalServerError, fmt.Sprintf("failed to generate upload pre-signed URL: %s", err.Error()))
+					return
+				}
+			}
 
 			// Stream the image file to S3 using the pre-signed URL
 			src, err := file.Open()
@@ -180,10 +314,38 @@ func main() {
 			ImagePath: imagePath,
 			ImageDownloadURL: ImageDownloadURL,
 			PDFUploadURL: PDFUploadURL,
-			JobID:     hash,
+			PDFUploadURLs: PDFUploadURLs,
+			ResultUploadURLs: ResultUploadURLs,
+			JobID:     jobID,
+			SourceLang: sourceLang,
+			TargetLang: targetLang,
+			TargetLangs: targetLangs,
+			TenantID:   tenantID,
+			GlossaryID: glossaryID,
+			OutputLayout: outputLayout,
+			OCRConfig: ocrConfig,
+			HighlightBelow: highlightBelow,
+			Preprocess: preprocessSteps,
+			Regions: regions,
 			SubmittedAt: time.Now(),
 		}
 
+		// the status goes first, a fast worker must not have its progress reset
+		data := redis_utils.InitialJobStatus(targetLangs)
+		data["source_lang"] = sourceLang
+		data["target_lang"] = targetLang
+		data["target_langs"] = strings.Join(targetLangs, ",")
+		data["glossary_id"] = glossaryID
+		data["output"] = outputLayout
+		data["ocr_config"] = ocrConfig.Key()
+		data["preprocess"] = strings.Join(preprocessSteps, ",")
+		data["pages_total"] = pages
+		err = redisClient.HSet(redisCtx, job.JobID, data).Err()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set status"})
+			return
+		}
+
 		body, err := json.Marshal(job)
 
 		err = ch.PublishWithContext(ctx,
@@ -197,45 +359,135 @@ func main() {
 			})
 	
 		if err != nil {
+			redisClient.HSet(redisCtx, job.JobID, "status", redis_utils.StatusFailed, "error", "Failed to publish message")
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to publish message"})
 			return
 		}
 
-		data := map[string]interface{}{
-			"response_time": 0,
-			"status":        "submitted",
-		}
-		err = redisClient.HSet(redisCtx, job.JobID, data).Err()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set status"})
-			return
-		}
-
 		// Respond with a success message
-		c.JSON(200, gin.H{"message": "Job submitted", "jobID": hash})
+		c.JSON(200, gin.H{"message": "Job submitted", "jobID": jobID, "source_lang": sourceLang, "target_lang": targetLang, "target_langs": targetLangs})
 	})
 
 	// Status endpoint
 	r.GET("/status/:jobID", func(c *gin.Context) {
 		jobID := c.Param("jobID")
-		status, err := redisClient.HGet(redisCtx, jobID, "status").Result()
+		fields, err := redisClient.HGetAll(redisCtx, jobID).Result()
 
-		if err == redis.Nil {
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get status"})
+			return
+		} else if fields["status"] == "" {
 			c.JSON(http.StatusNotFound, gin.H{"status": "not found"})
 			return
+		}
+
+		response := gin.H{
+			"status":      fields["status"],
+			"source_lang": fields["source_lang"],
+			"target_lang": fields["target_lang"],
+			"output":      fields["output"],
+		}
+		if fields["status"] == redis_utils.StatusFailed || fields["status"] == redis_utils.StatusPartiallyCompleted {
+			response["error"] = fields["error"]
+		}
+		if fields["target_langs"] != "" {
+			targetLangs := strings.Split(fields["target_langs"], ",")
+			response["target_langs"] = targetLangs
+			response["languages"] = redis_utils.LanguageProgress(fields, targetLangs)
+			if len(targetLangs) > 1 {
+				response["protected_spans_by_lang"] = redis_utils.ProtectedSpansByLang(fields, targetLangs)
+			}
+			response["progress"] = fields["langs_done"] + "/" + fields["langs_total"]
+		}
+		if fields["pages_total"] != "" {
+			response["pages"] = fields["pages_done"] + "/" + fields["pages_total"]
+		}
+		if fields["detected_lang"] != "" {
+			confidence, _ := strconv.ParseFloat(fields["detected_lang_confidence"], 64)
+			response["detected_lang"] = fields["detected_lang"]
+			response["detected_lang_confidence"] = confidence
+		}
+		if fields["protected_spans"] != "" {
+			protectedSpans, _ := strconv.Atoi(fields["protected_spans"])
+			response["protected_spans"] = protectedSpans
+		}
+		if fields["orientation"] != "" {
+			orientation, _ := strconv.Atoi(fields["orientation"])
+			response["orientation"] = orientation
+			response["orientation_detected"] = fields["orientation_detected"] == "1"
+			response["script"] = fields["script"]
+		}
+		if tables, ok := redis_utils.Tables(fields); ok {
+			response["tables"] = tables
+		}
+		if regions, ok := redis_utils.Regions(fields, strings.Split(fields["target_langs"], ",")); ok {
+			response["regions"] = regions
+		}
+		if confidence, ok := redis_utils.OCRConfidence(fields); ok {
+			for key, value := range confidence {
+				response[key] = value
+			}
+		}
+		c.JSON(http.StatusOK, response)
+	})
+	// Glossary endpoints, glossaries are stored per tenant (X-Tenant-ID header)
+	r.POST("/glossaries", func(c *gin.Context) {
+		var glossary models.Glossary
+		if err := c.ShouldBindJSON(&glossary); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid glossary: %s", err.Error())})
+			return
+		}
+		if err := translation.ValidateGlossary(&glossary); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		glossary.ID = uuid.New().String()
+		glossary.TenantID = getTenantID(c)
+		glossary.CreatedAt = time.Now()
+
+		if err := redis_utils.SaveGlossary(redisClient, redisCtx, &glossary); err != nil {
+			log.Printf("Failed to save glossary: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save glossary"})
+			return
+		}
+		c.JSON(http.StatusCreated, glossary)
+	})
+
+	r.GET("/glossaries/:glossaryID", func(c *gin.Context) {
+		glossary, err := redis_utils.GetGlossary(redisClient, redisCtx, getTenantID(c), c.Param("glossaryID"))
+		if err == redis_utils.ErrGlossaryNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "glossary not found"})
+			return
 		} else if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get status"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get glossary"})
 			return
 		}
+		c.JSON(http.StatusOK, glossary)
+	})
 
-		c.JSON(http.StatusOK, gin.H{"status": status})
+	r.DELETE("/glossaries/:glossaryID", func(c *gin.Context) {
+		err := redis_utils.DeleteGlossary(redisClient, redisCtx, getTenantID(c), c.Param("glossaryID"))
+		if err == redis_utils.ErrGlossaryNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "glossary not found"})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete glossary"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Glossary deleted"})
 	})
+
 	// Serve files normally
 	r.Static("/uploads", "./output")
 
 	// Download endpoint with Content-Disposition header
 	r.GET("/download/:filename", func(c *gin.Context) {
-		filename := c.Param("filename")
+		filename, err := outputFilename(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		filePath := "./output/" + filename
 
 		c.Header("Content-Disposition", "attachment; filename="+filename)
@@ -244,8 +496,12 @@ func main() {
 
 	// Serve file endpoint
 	r.GET("/cloud_download/:filename", func(c *gin.Context) {
-		filename := c.Param("filename")
-		filePath := "./output/" + filename
+		filename, err := outputFilename(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		filePath := "output/" + filename
 
 		presignedURL, err := aws_utils.GenerateDownloadURL(s3_bucket_name, filePath, 15*time.Minute)
 		if err != nil {
@@ -278,10 +534,50 @@ func main() {
 			healthStatus["rabbitmq"] = "unhealthy"
 			healthStatus["rabbitmq_error"] = "connection is closed"
 		}
+
+		// Check the translation provider circuit breakers of the translate workers
+		breakers, err := redis_utils.GetBreakerStatuses(redisClient, redisCtx, time.Minute)
+		if err != nil {
+			healthStatus["translation"] = "unknown"
+		} else {
+			open := 0
+			for _, breaker := range breakers {
+				if breaker.State == translation.BreakerOpen {
+					open++
+				}
+			}
+			switch {
+			case len(breakers) == 0:
+				healthStatus["translation"] = "no workers"
+			case open == len(breakers):
+				healthStatus["translation"] = "unhealthy"
+				healthStatus["translation_error"] = breakers[0].LastError
+			case open > 0:
+				healthStatus["translation"] = "degraded"
+			default:
+				healthStatus["translation"] = "ok"
+			}
+			healthStatus["translation_open_circuits"] = fmt.Sprintf("%d/%d", open, len(breakers))
+		}
 	
 		c.JSON(http.StatusOK, healthStatus)
 	})
 
+	// Endpoint to get translation memory hit/miss counters of all workers
+	r.GET("/translation-memory", func(c *gin.Context) {
+		hits, misses, err := redis_utils.GetTranslationMemoryStats(redisClient, redisCtx)
+		if err != nil {
+			log.Printf("Failed to get translation memory stats: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get translation memory stats"})
+			return
+		}
+		hitRate := 0.0
+		if hits+misses > 0 {
+			hitRate = float64(hits) / float64(hits+misses)
+		}
+		c.JSON(http.StatusOK, gin.H{"hits": hits, "misses": misses, "hit_rate": hitRate})
+	})
+
 	// Endpoint to get average response time
 	r.GET("/average-response-time", func(c *gin.Context) {
 		totalReq, avgTime, err := getAverageResponseTime()
@@ -336,6 +632,45 @@ func initRabbitMQ() (*amqp.Channel, error) {
 }
 
 
+// outputFilename resolves the requested result file. The format query
+// parameter selects the PDF (default) or an OCR result (hocr, alto, csv), the lang
+// parameter the language of a PDF of a job that was translated to several.
+func outputFilename(c *gin.Context) (string, error) {
+	filename := c.Param("filename")
+	format := c.Query("format")
+	if err := export.ValidateFormat(format); err != nil {
+		return "", err
+	}
+
+	jobID := strings.TrimSuffix(filename, ".pdf")
+	if format != "" && format != export.FormatPDF {
+		// OCR results do not depend on the target language
+		return jobID + export.Extension(format), nil
+	}
+
+	lang := c.Query("lang")
+	if lang == "" {
+		return filename, nil
+	}
+	// lang ends up in a path, only the languages of the job are accepted
+	targetLangs, _ := redisClient.HGet(redisCtx, jobID, "target_langs").Result()
+	langs := strings.Split(targetLangs, ",")
+	if !slices.Contains(langs, lang) {
+		return "", fmt.Errorf("lang %s is not a target language of job %s", lang, jobID)
+	}
+	return models.OutputName(jobID, lang, len(langs) > 1) + ".pdf", nil
+}
+
+// getTenantID returns the tenant of the request, taken from the X-Tenant-ID header
+func getTenantID(c *gin.Context) string {
+	tenantID := c.GetHeader("X-Tenant-ID")
+	if tenantID == "" {
+		return models.DefaultTenant
+	}
+	return tenantID
+}
+
+
 func initS3() {
 	aws_utils.InitS3Session(os.Getenv("AWS_REGION"), os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"))
 }
@@ -2,16 +2,26 @@ package main
 
 import (
 	"backend/models"
+	"backend/pkg/export"
 	"backend/pkg/ocr"
+	"backend/pkg/ocr/tesseract"
 	"backend/pkg/pdf"
+	"backend/pkg/preprocess"
 	"backend/pkg/segmentation"
+	"backend/pkg/tiff"
 	"backend/pkg/translation"
+	"backend/pkg/utils"
+	"context"
 	"fmt"
 	"log"
+	"maps"
 	"net/http"
 	"sync"
 	"time"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"flag"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -52,6 +62,25 @@ func getAverageResponseTime() time.Duration {
 
 
 var jobStatusMap = make(map[string]string)
+var jobMap = make(map[string]*models.Job)
+// per-language status and error of each job, keyed by job ID then language
+var jobLangStatusMap = make(map[string]map[string]map[string]string)
+
+// jobPagesMap and jobPagesDoneMap hold the pages of each job and how many
+// are recognized, guarded by jobStatusMutex
+var jobPagesMap = make(map[string]int)
+var jobPagesDoneMap = make(map[string]int)
+
+// jobRegionsMap holds the results of the jobs limited to regions of
+// interest, guarded by jobStatusMutex
+var jobRegionsMap = make(map[string]map[string]models.RegionResult)
+
+// engine recognizes the uploads, tests can set an ocr.FakeEngine
+var engine ocr.OCREngine = tesseract.NewEngine(true)
+
+// glossaries are kept in memory, keyed by tenant and glossary ID
+var glossaryMap = make(map[string]*models.Glossary)
+var glossaryMutex = &sync.Mutex{}
 var jobStatusMutex = &sync.Mutex{}
 
 func main() {
@@ -66,9 +95,15 @@ func main() {
 	flag.StringVar(&port, "port", os.Getenv("DEFAULT_PORT"), "port number")
 	flag.Parse()
 
+	// Initialize the translation backend
+	err = translation.Initialize(translation.ConfigFromEnv())
+	if err != nil {
+		log.Fatalf("Error initializing translator: %v", err)
+	}
+
 	// Initialize the Tesseract client
-	ocr.Initialize()
-	defer ocr.Cleanup() // Ensure the client is closed when the server shuts down
+	tesseract.Initialize()
+	defer tesseract.Cleanup() // Ensure the client is closed when the server shuts down
 
 	// Create a Gin router
 	r := gin.Default()
@@ -76,9 +111,9 @@ func main() {
 	// Config CORS middleware
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"}, // Adjust this to match your frontend's origin
-		AllowMethods:     []string{"GET", "POST"},
-		AllowHeaders:     []string{"Origin", "Content-Type"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "DELETE"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "X-Tenant-ID"},
+		ExposeHeaders:    []string{"Content-Length", "X-Source-Lang", "X-Target-Lang", "X-Detected-Lang", "X-Detected-Lang-Confidence", "X-Protected-Spans"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -95,6 +130,101 @@ func main() {
 			c.String(http.StatusBadRequest, fmt.Sprintf("get file err: %s", err.Error()))
 			return
 		}
+
+		// target_lang may be repeated or comma separated, target_langs is accepted too
+		targetLangs := translation.NormalizeTargetLanguages(append(c.PostFormArray("target_lang"), c.PostForm("target_langs")))
+		if len(targetLangs) > translation.MaxTargetLanguages {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d target languages are allowed", translation.MaxTargetLanguages)})
+			return
+		}
+		sourceLang, targetLang := translation.NormalizeLanguages(c.PostForm("source_lang"), targetLangs[0])
+		for _, lang := range targetLangs {
+			err = translation.ValidateLanguages(sourceLang, lang)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		outputLayout := c.DefaultPostForm("output", pdf.LayoutTranslated)
+		if err := pdf.ValidateLayout(outputLayout); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Tesseract settings, e.g. ocr_lang=vie+eng&ocr_psm=6&ocr_variables={"tessedit_char_whitelist":"0123456789"}
+		ocrConfig, err := models.ParseOCRConfig(c.PostForm("ocr_lang"), c.PostForm("ocr_psm"), c.PostForm("ocr_oem"), c.PostForm("ocr_variables"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := tesseract.CheckLanguages(ocrConfig); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// image cleanup before OCR, a preset (scan, photo, fax) or steps, e.g. preprocess=grayscale,deskew,binarize
+		preprocessSteps, err := preprocess.Parse(c.PostForm("preprocess"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// regions=[{"name":"address","x":120,"y":80,"width":600,"height":200}] recognizes only
+		// those rectangles, in pixels or, with "unit":"relative", fractions of the image
+		regions, err := ocr.ParseRegions(c.PostForm("regions"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// highlight_low_confidence=true marks unreliable OCR words in the PDF
+		var highlightBelow float64
+		if highlight, _ := strconv.ParseBool(c.PostForm("highlight_low_confidence")); highlight {
+			highlightBelow = ocr.WordConfidenceThreshold()
+		}
+
+		// multi-page TIFF scans are recognized page by page
+		pages, err := utils.CountPagesFromFormFile(file)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// regions are cropped from the decoded image, TIFF pages are only read by Tesseract
+		if len(regions) > 0 {
+			isTIFF, err := utils.IsTIFFFromFormFile(file)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+				return
+			}
+			if isTIFF {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "regions are not supported for TIFF files"})
+				return
+			}
+		}
+
+		tenantID := getTenantID(c)
+		var glossary *models.Glossary
+		if glossaryID := c.PostForm("glossary_id"); glossaryID != "" {
+			glossaryMutex.Lock()
+			glossary = glossaryMap[tenantID+":"+glossaryID]
+			glossaryMutex.Unlock()
+			if glossary == nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown glossary_id"})
+				return
+			}
+			// the glossary is applied to the target languages it matches
+			matches := false
+			for _, lang := range targetLangs {
+				matches = matches || translation.GlossaryMatches(glossary, sourceLang, lang)
+			}
+			if !matches {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Glossary does not match the requested languages"})
+				return
+			}
+		}
+
 		imagePath := "./uploads/" + file.Filename
 		// Save the file to a specific location
 		err = c.SaveUploadedFile(file, imagePath)
@@ -111,44 +241,238 @@ func main() {
 		job := &models.Job{
 			ImagePath: imagePath,
 			JobID:     jobID,
+			SourceLang: sourceLang,
+			TargetLang: targetLang,
+			TargetLangs: targetLangs,
+			TenantID:   tenantID,
+			OutputLayout: outputLayout,
+			OCRConfig: ocrConfig,
+			HighlightBelow: highlightBelow,
+			Preprocess: preprocessSteps,
+			Regions: regions,
 			SubmittedAt: time.Now(),
 		}
+		if glossary != nil {
+			job.GlossaryID = glossary.ID
+		}
 
 		// Initialize job status to "pending"
 		jobStatusMutex.Lock()
 		jobStatusMap[job.JobID] = "pending"
+		jobMap[job.JobID] = job
+		langStatus := make(map[string]map[string]string, len(targetLangs))
+		for _, lang := range targetLangs {
+			langStatus[lang] = map[string]string{"status": "pending"}
+		}
+		jobLangStatusMap[job.JobID] = langStatus
+		jobPagesMap[job.JobID] = pages
 		jobStatusMutex.Unlock()
 
 		// process immediately
 
-		originalText, err := ocr.OCRFilter(imagePath)
+		var document *models.Document
+		orientation, orientationDetected := 0, false
+		if len(regions) > 0 {
+			// only the regions of interest are recognized
+			document, err = ocr.OCRRegions(c.Request.Context(), engine, imagePath, regions, preprocessSteps, ocrConfig, jobID)
+			if err == nil {
+				jobStatusMutex.Lock()
+				jobPagesDoneMap[jobID]++
+				jobStatusMutex.Unlock()
+			}
+		} else if tiff.IsTIFF(imagePath) {
+			// the pages of a scan are recognized in parallel
+			document, err = ocr.OCRTIFF(c.Request.Context(), engine, imagePath, preprocessSteps, ocrConfig, jobID, func() {
+				jobStatusMutex.Lock()
+				jobPagesDoneMap[jobID]++
+				jobStatusMutex.Unlock()
+			})
+			if err == nil {
+				orientation = document.Pages[0].Orientation
+				orientationDetected = document.Pages[0].OrientationDetected
+			}
+		} else {
+			var prepared *ocr.PreparedImage
+			prepared, err = ocr.PrepareImage(engine, imagePath, preprocessSteps, ocrConfig, jobID)
+			if err != nil {
+				log.Printf("Job %s failed: %v", job.JobID, err)
+				failJob(job, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to preprocess image", "reason": err.Error()})
+				return
+			}
+			defer prepared.Cleanup()
+			orientation = prepared.Orientation
+			orientationDetected = prepared.OrientationDetected
+
+			document, err = engine.Recognize(prepared.Path, ocrConfig)
+			if err == nil {
+				if tableErr := ocr.RecognizeTables(c.Request.Context(), engine, prepared.Path, &document.Pages[0], ocrConfig); tableErr != nil {
+					log.Printf("Failed to recognize the tables of job %s: %v", jobID, tableErr)
+				}
+				prepared.Unmap(document)
+				jobStatusMutex.Lock()
+				jobPagesDoneMap[jobID]++
+				jobStatusMutex.Unlock()
+			}
+		}
 		if err != nil {
-			log.Printf("Job %s failed", job.JobID)
-			jobStatusMutex.Lock()
-			jobStatusMap[job.JobID] = "failed"
-			jobStatusMutex.Unlock()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process image"})
+			log.Printf("Job %s failed: %v", job.JobID, err)
+			failJob(job, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process image", "reason": err.Error()})
+			return
 		}
 
-		translatedText := translation.TranslateFilter(originalText)
-		result, err := pdf.ExportPDF(translatedText, job.JobID, margins)
+		originalText := document.Text()
+		sourceLang, detected := translation.ResolveSourceLang(originalText, job.SourceLang)
+		jobStatusMutex.Lock()
+		job.Document = document
+		job.ExtractedText = originalText
+		job.Orientation = orientation
+		job.OrientationDetected = orientationDetected
+		job.Script = ocr.DetectScript(originalText)
+		job.DetectedLang, job.DetectedLangConfidence = detected.Lang, detected.Confidence
+		ocr.ScoreJob(job)
+		jobStatusMutex.Unlock()
+		if job.LowConfidence {
+			log.Printf("Job %s has a low OCR confidence: %.1f", job.JobID, job.OCRConfidence)
+		}
+
+		// hOCR and ALTO next to the PDF, for /download?format=; the
+		// translation does not depend on them
+		resultLang := sourceLang
+		if resultLang == translation.AutoDetect {
+			resultLang = ""
+		}
+		err = export.SaveOCRResults(job, resultLang)
 		if err != nil {
-			log.Printf("Job %s failed", job.JobID)
+			log.Printf("Failed to save the OCR results of job %s: %v", job.JobID, err)
+		}
+
+		// translate to every target language, one failing does not stop the others
+		results := map[string]string{}
+		regionTranslations := map[string]map[string]string{}
+		var lastErr error
+		for _, lang := range targetLangs {
 			jobStatusMutex.Lock()
-			jobStatusMap[job.JobID] = "failed"
+			langJob := job.ForTargetLang(lang)
 			jobStatusMutex.Unlock()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate PDF"})
+			report, err := translation.TranslateDocument(langJob.Document, translation.Options{
+				SourceLang: sourceLang,
+				TargetLang: lang,
+				Glossary:   glossary,
+			})
+			jobStatusMutex.Lock()
+			if job.ProtectedSpansByLang == nil {
+				job.ProtectedSpansByLang = map[string]int{}
+			}
+			job.ProtectedSpansByLang[lang] = report.ProtectedSpans
+			if lang == targetLang {
+				job.ProtectedSpans = report.ProtectedSpans
+			}
+			jobStatusMutex.Unlock()
+			if err == nil {
+				results[lang], err = pdf.ExportPDF(langJob.Document.TranslatedText(), langJob.OutputName(), margins, pdf.Options{
+					Layout:       outputLayout,
+					OriginalText: originalText,
+					SourceLang:   sourceLang,
+					TargetLang:   lang,
+					Document:     langJob.Document,
+					HighlightBelow: highlightBelow,
+					ImagePath:    imagePath,
+				})
+			}
+			if err != nil {
+				log.Printf("Job %s failed for %s: %v", job.JobID, lang, err)
+				lastErr = err
+				setLangStatus(job.JobID, lang, "failed", err.Error())
+				continue
+			}
+			setLangStatus(job.JobID, lang, "completed", "")
+			regionTranslations[lang] = langJob.Document.RegionTranslations()
 		}
 
-		job.OutFilePath = result
+		if len(results) == 0 {
+			failJob(job, lastErr)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to translate text", "reason": lastErr.Error()})
+			return
+		}
+
+		status := "completed"
+		if lastErr != nil {
+			status = "partially_completed"
+		}
+		jobStatusMutex.Lock()
+		jobStatusMap[job.JobID] = status
+		if lastErr != nil {
+			job.FailureReason = lastErr.Error()
+		}
+		job.OutFilePath = results[targetLang]
 		job.CompletedAt = time.Now()
 		job.ResponseTime = job.CompletedAt.Sub(job.SubmittedAt)
+		jobStatusMutex.Unlock()
 		// Update average response time
 		updateAverageResponseTime(job.ResponseTime)
 
+		if len(regions) > 0 {
+			// the regions are answered as JSON, the PDFs stay downloadable
+			regionResults := models.RegionResults(document.RegionTexts(), regionTranslations)
+			jobStatusMutex.Lock()
+			jobRegionsMap[job.JobID] = regionResults
+			response := gin.H{
+				"jobID":        job.JobID,
+				"status":       status,
+				"source_lang":  job.SourceLang,
+				"target_langs": targetLangs,
+				"languages":    copyLangStatus(job.JobID),
+				"regions":      regionResults,
+			}
+			jobStatusMutex.Unlock()
+			c.JSON(http.StatusOK, response)
+			return
+		}
+
+		if len(targetLangs) > 1 {
+			// several PDFs can not be returned in one response, point to the downloads instead
+			downloads := map[string]string{}
+			for lang := range results {
+				downloads[lang] = "/download/" + job.JobID + ".pdf?lang=" + lang
+			}
+			jobStatusMutex.Lock()
+			response := gin.H{
+				"jobID":       job.JobID,
+				"status":      status,
+				"source_lang": job.SourceLang,
+				"target_langs": targetLangs,
+				"languages":   copyLangStatus(job.JobID),
+				"downloads":   downloads,
+			}
+			if job.DetectedLang != "" {
+				response["detected_lang"] = job.DetectedLang
+				response["detected_lang_confidence"] = job.DetectedLangConfidence
+			}
+			response["protected_spans"] = job.ProtectedSpans
+			response["protected_spans_by_lang"] = job.ProtectedSpansByLang
+			response["ocr_confidence"] = job.OCRConfidence
+			response["page_confidences"] = job.PageConfidences
+			response["low_confidence"] = job.LowConfidence
+			jobStatusMutex.Unlock()
+			c.JSON(http.StatusOK, response)
+			return
+		}
+
+		result := job.OutFilePath
 		filename := job.JobID + ".pdf"
 		// Respond with a success message
 		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Header("X-Source-Lang", job.SourceLang)
+		c.Header("X-Target-Lang", job.TargetLang)
+		c.Header("X-Protected-Spans", strconv.Itoa(job.ProtectedSpans))
+		c.Header("X-OCR-Confidence", strconv.FormatFloat(job.OCRConfidence, 'f', 2, 64))
+		c.Header("X-Low-Confidence", strconv.FormatBool(job.LowConfidence))
+		if job.DetectedLang != "" {
+			c.Header("X-Detected-Lang", job.DetectedLang)
+			c.Header("X-Detected-Lang-Confidence", strconv.FormatFloat(job.DetectedLangConfidence, 'f', 3, 64))
+		}
 		c.File(result)
 	})
 
@@ -157,25 +481,157 @@ func main() {
 		jobID := c.Param("jobID")
 		jobStatusMutex.Lock()
 		status, exists := jobStatusMap[jobID]
+		// copy the job while locked, the upload handler is still filling it in
+		var job *models.Job
+		if stored, ok := jobMap[jobID]; ok {
+			snapshot := *stored
+			snapshot.ProtectedSpansByLang = maps.Clone(stored.ProtectedSpansByLang)
+			job = &snapshot
+		}
+		languages := copyLangStatus(jobID)
+		pages := fmt.Sprintf("%d/%d", jobPagesDoneMap[jobID], jobPagesMap[jobID])
+		regionResults := jobRegionsMap[jobID]
 		jobStatusMutex.Unlock()
 		if !exists {
 			c.JSON(http.StatusNotFound, gin.H{"status": "not found"})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"status": status})
+		response := gin.H{"status": status}
+		if job != nil {
+			response["pages"] = pages
+			response["source_lang"] = job.SourceLang
+			response["target_lang"] = job.TargetLang
+			response["output"] = job.OutputLayout
+			if status == "failed" || status == "partially_completed" {
+				response["error"] = job.FailureReason
+			}
+			if len(job.TargetLangs) > 0 {
+				response["target_langs"] = job.TargetLangs
+				response["languages"] = languages
+				if len(job.TargetLangs) > 1 {
+					response["protected_spans_by_lang"] = job.ProtectedSpansByLang
+				}
+			}
+			if job.DetectedLang != "" {
+				response["detected_lang"] = job.DetectedLang
+				response["detected_lang_confidence"] = job.DetectedLangConfidence
+			}
+			response["protected_spans"] = job.ProtectedSpans
+			if job.Document != nil {
+				response["orientation"] = job.Orientation
+				response["orientation_detected"] = job.OrientationDetected
+				response["script"] = job.Script
+				response["tables"] = tableCells(job.Document)
+			}
+			if regionResults != nil {
+				response["regions"] = regionResults
+			}
+			if job.PageConfidences != nil {
+				response["ocr_confidence"] = job.OCRConfidence
+				response["page_confidences"] = job.PageConfidences
+				response["low_confidence"] = job.LowConfidence
+			}
+		}
+		c.JSON(http.StatusOK, response)
+	})
+	// Glossary endpoints, glossaries are stored per tenant (X-Tenant-ID header)
+	r.POST("/glossaries", func(c *gin.Context) {
+		var glossary models.Glossary
+		if err := c.ShouldBindJSON(&glossary); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid glossary: %s", err.Error())})
+			return
+		}
+		if err := translation.ValidateGlossary(&glossary); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		glossary.ID = uuid.New().String()
+		glossary.TenantID = getTenantID(c)
+		glossary.CreatedAt = time.Now()
+
+		glossaryMutex.Lock()
+		glossaryMap[glossary.TenantID+":"+glossary.ID] = &glossary
+		glossaryMutex.Unlock()
+		c.JSON(http.StatusCreated, glossary)
+	})
+
+	r.GET("/glossaries/:glossaryID", func(c *gin.Context) {
+		glossaryMutex.Lock()
+		glossary, exists := glossaryMap[getTenantID(c)+":"+c.Param("glossaryID")]
+		glossaryMutex.Unlock()
+		if !exists {
+			c.JSON(http.StatusNotFound, gin.H{"error": "glossary not found"})
+			return
+		}
+		c.JSON(http.StatusOK, glossary)
+	})
+
+	r.DELETE("/glossaries/:glossaryID", func(c *gin.Context) {
+		key := getTenantID(c) + ":" + c.Param("glossaryID")
+		glossaryMutex.Lock()
+		_, exists := glossaryMap[key]
+		delete(glossaryMap, key)
+		glossaryMutex.Unlock()
+		if !exists {
+			c.JSON(http.StatusNotFound, gin.H{"error": "glossary not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Glossary deleted"})
 	})
+
 	// Serve files normally
 	r.Static("/uploads", "./output")
 
 	// Download endpoint with Content-Disposition header
 	r.GET("/download/:filename", func(c *gin.Context) {
 		filename := c.Param("filename")
+		// the format query parameter selects the PDF (default) or an OCR result
+		format := c.Query("format")
+		if err := export.ValidateFormat(format); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if format != "" && format != export.FormatPDF {
+			filename = strings.TrimSuffix(filename, ".pdf") + export.Extension(format)
+		} else if lang := c.Query("lang"); lang != "" {
+			// the lang query parameter selects the language of a multi-language job
+			jobID := strings.TrimSuffix(filename, ".pdf")
+			var langs []string
+			jobStatusMutex.Lock()
+			if job, exists := jobMap[jobID]; exists {
+				langs = job.TargetLanguages()
+			}
+			jobStatusMutex.Unlock()
+			// lang ends up in a path, only the languages of the job are accepted
+			if !slices.Contains(langs, lang) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("lang %s is not a target language of job %s", lang, jobID)})
+				return
+			}
+			filename = models.OutputName(jobID, lang, len(langs) > 1) + ".pdf"
+		}
 		filePath := "./output/" + filename
 
 		c.Header("Content-Disposition", "attachment; filename="+filename)
 		c.File(filePath)
 	})
 
+	// Health check endpoint
+	r.GET("/health", func(c *gin.Context) {
+		healthStatus := gin.H{
+			"translation": "ok",
+			"timestamp":   time.Now().Format(time.RFC3339),
+		}
+		if breaker := translation.Breaker(); breaker != nil {
+			status := breaker.Status()
+			healthStatus["translation_circuit"] = status
+			if status.State == translation.BreakerOpen {
+				healthStatus["translation"] = "unhealthy"
+			}
+		}
+		c.JSON(http.StatusOK, healthStatus)
+	})
+
 	// Endpoint to get average response time
 	r.GET("/average-response-time", func(c *gin.Context) {
 		avgTime := getAverageResponseTime()
@@ -187,6 +643,54 @@ func main() {
 	r.Run(exposed_port)
 }
 
+// failJob marks the job as failed and records the reason for /status
+func failJob(job *models.Job, err error) {
+	jobStatusMutex.Lock()
+	defer jobStatusMutex.Unlock()
+	jobStatusMap[job.JobID] = "failed"
+	job.FailureReason = err.Error()
+}
+
+// setLangStatus records the outcome of one target language of a job
+func setLangStatus(jobID, lang, status, reason string) {
+	jobStatusMutex.Lock()
+	defer jobStatusMutex.Unlock()
+	entry := map[string]string{"status": status}
+	if reason != "" {
+		entry["error"] = reason
+	}
+	jobLangStatusMap[jobID][lang] = entry
+}
+
+// tableCells returns the cell texts of the tables of doc by row, as the
+// status of the async server reports them
+func tableCells(doc *models.Document) [][][]string {
+	cells := make([][][]string, 0)
+	for _, table := range doc.Tables() {
+		cells = append(cells, table.Cells())
+	}
+	return cells
+}
+
+// copyLangStatus returns a copy of the per-language status of a job,
+// the caller must hold jobStatusMutex
+func copyLangStatus(jobID string) map[string]map[string]string {
+	languages := map[string]map[string]string{}
+	for lang, entry := range jobLangStatusMap[jobID] {
+		languages[lang] = entry
+	}
+	return languages
+}
+
+// getTenantID returns the tenant of the request, taken from the X-Tenant-ID header
+func getTenantID(c *gin.Context) string {
+	tenantID := c.GetHeader("X-Tenant-ID")
+	if tenantID == "" {
+		return models.DefaultTenant
+	}
+	return tenantID
+}
+
 func worker(id int, jobs <-chan *models.Job) {
 	for job := range jobs {
 		start_time := time.Now()
@@ -202,9 +706,9 @@ func worker(id int, jobs <-chan *models.Job) {
 		log.Printf("Image Spliting took %v\n", time.Since(splitTime))
 		// Perform the OCR, translation, and PDF generation here
 		OCRTime := time.Now()
-		originalText, err := ocr.OCRFilterConcurrent(segmentPaths)
+		originalText, err := ocr.OCRFilterConcurrent(context.Background(), engine, segmentPaths, job.OCRConfig)
 		if err != nil {
-			log.Printf("Job %s failed", id, job.JobID)
+			log.Printf("Worker %d: job %s failed", id, job.JobID)
 			jobStatusMutex.Lock()
 			jobStatusMap[job.JobID] = "failed"
 			jobStatusMutex.Unlock()
@@ -213,15 +717,30 @@ func worker(id int, jobs <-chan *models.Job) {
 
 		log.Printf("OCR took %v\n", time.Since(OCRTime))
 		TranslationTime := time.Now()
-		translatedText := translation.TranslateFilter(originalText)
+		translatedText, err := translation.TranslateFilter(originalText, translation.Options{
+			SourceLang: job.SourceLang,
+			TargetLang: job.TargetLang,
+		})
+		if err != nil {
+			log.Printf("Worker %d: job %s failed: %v", id, job.JobID, err)
+			jobStatusMutex.Lock()
+			jobStatusMap[job.JobID] = "failed"
+			jobStatusMutex.Unlock()
+			continue
+		}
 		log.Printf("Translation took %v\n", time.Since(TranslationTime))
 		margins := map[string]float64{
 			"left":  30,
 			"top":   50,
 			"right": 30}
-		result, err := pdf.ExportPDF(translatedText, job.JobID, margins)
+		result, err := pdf.ExportPDF(translatedText, job.JobID, margins, pdf.Options{
+			Layout:       job.OutputLayout,
+			OriginalText: originalText,
+			SourceLang:   job.SourceLang,
+			TargetLang:   job.TargetLang,
+		})
 		if err != nil {
-			log.Printf("Job %s failed", id, job.JobID)
+			log.Printf("Worker %d: job %s failed", id, job.JobID)
 			jobStatusMutex.Lock()
 			jobStatusMap[job.JobID] = "failed"
 			jobStatusMutex.Unlock()
@@ -0,0 +1,279 @@
+package models
+
+import (
+	"strings"
+)
+
+// Document is the structured result of OCR, filled in with translations by
+// the translate stage. Coordinates are pixels of the page image.
+type Document struct {
+	Pages []Page `json:"pages"`
+}
+
+type Page struct {
+	Number int `json:"number"`
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+	// Orientation is by how many degrees, clockwise, the page image was
+	// turned upright before OCR; 0 means upright only if OrientationDetected
+	Orientation         int     `json:"orientation,omitempty"`
+	OrientationDetected bool    `json:"orientation_detected,omitempty"`
+	Blocks              []Block `json:"blocks"`
+}
+
+// Block is a paragraph, the unit of translation
+type Block struct {
+	BBox  BBox   `json:"bbox"`
+	Lines []Line `json:"lines"`
+	// Text is the paragraph as running text (lines joined, words broken
+	// across lines dehyphenated), TranslatedText its translation
+	Text           string `json:"text"`
+	TranslatedText string `json:"translated_text,omitempty"`
+	// Table is set when the block is a ruled table, its cells recognized one
+	// by one; Text then holds the rows, the cells separated by tabs, and
+	// Lines the words Tesseract read across the table
+	Table *Table `json:"table,omitempty"`
+	// Region names the region of interest the block was recognized from,
+	// see Job.Regions
+	Region string `json:"region,omitempty"`
+}
+
+// Table is a grid of cells, every row has the same number of cells
+type Table struct {
+	Rows [][]Cell `json:"rows"`
+}
+
+type Cell struct {
+	BBox           BBox   `json:"bbox"`
+	Text           string `json:"text"`
+	TranslatedText string `json:"translated_text,omitempty"`
+}
+
+// Text returns the rows of the table on lines of their own, the cells
+// separated by tabs
+func (t *Table) Text() string {
+	return t.join(func(cell Cell) string { return cell.Text })
+}
+
+// TranslatedText is Text with the translated cells
+func (t *Table) TranslatedText() string {
+	return t.join(func(cell Cell) string { return cell.TranslatedText })
+}
+
+func (t *Table) join(text func(Cell) string) string {
+	rows := make([]string, len(t.Rows))
+	for r, row := range t.Rows {
+		cells := make([]string, len(row))
+		for c, cell := range row {
+			cells[c] = text(cell)
+		}
+		rows[r] = strings.Join(cells, "\t")
+	}
+	return strings.Join(rows, "\n")
+}
+
+// Cells returns the cell texts by row
+func (t *Table) Cells() [][]string {
+	cells := make([][]string, len(t.Rows))
+	for r, row := range t.Rows {
+		cells[r] = make([]string, len(row))
+		for c, cell := range row {
+			cells[r][c] = cell.Text
+		}
+	}
+	return cells
+}
+
+type Line struct {
+	BBox  BBox   `json:"bbox"`
+	Words []Word `json:"words"`
+}
+
+type Word struct {
+	BBox       BBox    `json:"bbox"`
+	Text       string  `json:"text"`
+	Confidence float64 `json:"confidence"`
+}
+
+// BBox is a bounding box, X1 and Y1 are exclusive
+type BBox struct {
+	X0 int `json:"x0"`
+	Y0 int `json:"y0"`
+	X1 int `json:"x1"`
+	Y1 int `json:"y1"`
+}
+
+func (b BBox) Width() int {
+	return b.X1 - b.X0
+}
+
+func (b BBox) Height() int {
+	return b.Y1 - b.Y0
+}
+
+func (b BBox) Empty() bool {
+	return b.X1 <= b.X0 || b.Y1 <= b.Y0
+}
+
+// Union returns the smallest box containing b and other
+func (b BBox) Union(other BBox) BBox {
+	if b.Empty() {
+		return other
+	}
+	if other.Empty() {
+		return b
+	}
+	return BBox{
+		X0: min(b.X0, other.X0),
+		Y0: min(b.Y0, other.Y0),
+		X1: max(b.X1, other.X1),
+		Y1: max(b.Y1, other.Y1),
+	}
+}
+
+// Blocks returns pointers to every block of the document in reading order
+func (d *Document) Blocks() []*Block {
+	var blocks []*Block
+	for p := range d.Pages {
+		for b := range d.Pages[p].Blocks {
+			blocks = append(blocks, &d.Pages[p].Blocks[b])
+		}
+	}
+	return blocks
+}
+
+// Tables returns the tables of the document in reading order
+func (d *Document) Tables() []*Table {
+	var tables []*Table
+	for _, block := range d.Blocks() {
+		if block.Table != nil {
+			tables = append(tables, block.Table)
+		}
+	}
+	return tables
+}
+
+// RegionTexts returns the text of every region of interest by name
+func (d *Document) RegionTexts() map[string]string {
+	return d.regions(func(block *Block) string { return block.Text })
+}
+
+// RegionTranslations returns the translation of every region of interest by name
+func (d *Document) RegionTranslations() map[string]string {
+	return d.regions(func(block *Block) string { return block.TranslatedText })
+}
+
+func (d *Document) regions(text func(*Block) string) map[string]string {
+	regions := map[string]string{}
+	for _, block := range d.Blocks() {
+		if block.Region != "" {
+			regions[block.Region] = text(block)
+		}
+	}
+	return regions
+}
+
+// Text returns the paragraphs of the document separated by blank lines,
+// which is what Job.ExtractedText holds
+func (d *Document) Text() string {
+	return d.join(func(block *Block) string { return block.Text })
+}
+
+// TranslatedText returns the translated paragraphs separated by blank lines
+func (d *Document) TranslatedText() string {
+	return d.join(func(block *Block) string { return block.TranslatedText })
+}
+
+func (d *Document) join(text func(*Block) string) string {
+	var paragraphs []string
+	for _, block := range d.Blocks() {
+		if t := text(block); t != "" {
+			paragraphs = append(paragraphs, t)
+		}
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// Clone returns a deep copy, so that each target language of a job can
+// carry its own translations
+func (d *Document) Clone() *Document {
+	if d == nil {
+		return nil
+	}
+	clone := &Document{Pages: make([]Page, len(d.Pages))}
+	for p, page := range d.Pages {
+		page.Blocks = append([]Block(nil), page.Blocks...)
+		for b := range page.Blocks {
+			lines := append([]Line(nil), page.Blocks[b].Lines...)
+			for l := range lines {
+				lines[l].Words = append([]Word(nil), lines[l].Words...)
+			}
+			page.Blocks[b].Lines = lines
+			if table := page.Blocks[b].Table; table != nil {
+				rows := make([][]Cell, len(table.Rows))
+				for r, row := range table.Rows {
+					rows[r] = append([]Cell(nil), row...)
+				}
+				page.Blocks[b].Table = &Table{Rows: rows}
+			}
+		}
+		clone.Pages[p] = page
+	}
+	return clone
+}
+
+// MeanConfidence is the average Tesseract confidence (0-100) of the words of
+// the block, words without a confidence are skipped. ok is false if there
+// are none.
+func (b *Block) MeanConfidence() (mean float64, ok bool) {
+	sum, count := b.confidenceSum()
+	if count == 0 {
+		return 0, false
+	}
+	return sum / float64(count), true
+}
+
+func (b *Block) confidenceSum() (float64, int) {
+	var sum float64
+	count := 0
+	for _, line := range b.Lines {
+		for _, word := range line.Words {
+			if word.Confidence >= 0 {
+				sum += word.Confidence
+				count++
+			}
+		}
+	}
+	return sum, count
+}
+
+// MeanConfidence is the average word confidence of the page, see Block.MeanConfidence
+func (p *Page) MeanConfidence() (float64, bool) {
+	var sum float64
+	count := 0
+	for b := range p.Blocks {
+		blockSum, blockCount := p.Blocks[b].confidenceSum()
+		sum += blockSum
+		count += blockCount
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return sum / float64(count), true
+}
+
+// MeanConfidence is the average word confidence of the document, every word
+// weighs the same whatever its page
+func (d *Document) MeanConfidence() (float64, bool) {
+	var sum float64
+	count := 0
+	for _, block := range d.Blocks() {
+		blockSum, blockCount := block.confidenceSum()
+		sum += blockSum
+		count += blockCount
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return sum / float64(count), true
+}
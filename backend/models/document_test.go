@@ -0,0 +1,165 @@
+package models
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// testDocument has two pages: a paragraph and a table on the first, an
+// untranslated paragraph on the second
+func testDocument() *Document {
+	return &Document{Pages: []Page{
+		{Number: 1, Blocks: []Block{
+			{
+				BBox: BBox{X0: 10, Y0: 10, X1: 200, Y1: 40},
+				Lines: []Line{{Words: []Word{
+					{Text: "Hello", Confidence: 90},
+					{Text: "world", Confidence: 80},
+				}}},
+				Text:           "Hello world",
+				TranslatedText: "Xin chào thế giới",
+				Region:         "title",
+			},
+			{
+				Table: &Table{Rows: [][]Cell{
+					{{Text: "Item", TranslatedText: "Mục"}, {Text: "Price", TranslatedText: "Giá"}},
+					{{Text: "Tea", TranslatedText: "Trà"}, {Text: "2", TranslatedText: "2"}},
+				}},
+				Text:           "Item\tPrice\nTea\t2",
+				TranslatedText: "Mục\tGiá\nTrà\t2",
+			},
+		}},
+		{Number: 2, Blocks: []Block{
+			{
+				Lines: []Line{{Words: []Word{
+					{Text: "Goodbye", Confidence: 40},
+					{Text: "?", Confidence: -1},
+				}}},
+				Text: "Goodbye",
+			},
+		}},
+	}}
+}
+
+func TestDocumentBlocks(t *testing.T) {
+	doc := testDocument()
+	blocks := doc.Blocks()
+
+	var texts []string
+	for _, block := range blocks {
+		texts = append(texts, block.Text)
+	}
+	if want := []string{"Hello world", "Item\tPrice\nTea\t2", "Goodbye"}; !reflect.DeepEqual(texts, want) {
+		t.Fatalf("Blocks() texts = %q, want %q", texts, want)
+	}
+
+	// the blocks are the document's, not copies
+	blocks[2].TranslatedText = "Tạm biệt"
+	if got := doc.Pages[1].Blocks[0].TranslatedText; got != "Tạm biệt" {
+		t.Errorf("TranslatedText after setting it through Blocks() = %q, want Tạm biệt", got)
+	}
+}
+
+func TestDocumentText(t *testing.T) {
+	doc := testDocument()
+
+	if got, want := doc.Text(), "Hello world\n\nItem\tPrice\nTea\t2\n\nGoodbye"; got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+	// the untranslated paragraph is skipped
+	if got, want := doc.TranslatedText(), "Xin chào thế giới\n\nMục\tGiá\nTrà\t2"; got != want {
+		t.Errorf("TranslatedText() = %q, want %q", got, want)
+	}
+	if got, want := doc.RegionTexts(), map[string]string{"title": "Hello world"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RegionTexts() = %v, want %v", got, want)
+	}
+}
+
+func TestDocumentTables(t *testing.T) {
+	doc := testDocument()
+	tables := doc.Tables()
+	if len(tables) != 1 {
+		t.Fatalf("Tables() = %d tables, want 1", len(tables))
+	}
+	table := tables[0]
+
+	if got, want := table.Cells(), [][]string{{"Item", "Price"}, {"Tea", "2"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Cells() = %q, want %q", got, want)
+	}
+	if got, want := table.Text(), "Item\tPrice\nTea\t2"; got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+	if got, want := table.TranslatedText(), "Mục\tGiá\nTrà\t2"; got != want {
+		t.Errorf("TranslatedText() = %q, want %q", got, want)
+	}
+}
+
+func TestDocumentClone(t *testing.T) {
+	doc := testDocument()
+	clone := doc.Clone()
+	if !reflect.DeepEqual(clone, doc) {
+		t.Fatalf("Clone() = %+v, want %+v", clone, doc)
+	}
+
+	// changing any level of the clone leaves the original as it was
+	clone.Pages[0].Blocks[0].TranslatedText = "Bonjour le monde"
+	clone.Pages[0].Blocks[0].Lines[0].Words[0].Text = "Bonjour"
+	clone.Pages[0].Blocks[1].Table.Rows[1][0].TranslatedText = "Thé"
+	clone.Pages[1].Blocks = append(clone.Pages[1].Blocks, Block{Text: "added"})
+	if !reflect.DeepEqual(doc, testDocument()) {
+		t.Errorf("original after changing the clone = %+v, want it unchanged", doc)
+	}
+
+	var nilDoc *Document
+	if got := nilDoc.Clone(); got != nil {
+		t.Errorf("Clone() of nil = %+v, want nil", got)
+	}
+}
+
+func TestBBoxUnion(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b BBox
+		want BBox
+	}{
+		{"overlapping", BBox{X0: 0, Y0: 0, X1: 10, Y1: 10}, BBox{X0: 5, Y0: 5, X1: 20, Y1: 15}, BBox{X0: 0, Y0: 0, X1: 20, Y1: 15}},
+		{"apart", BBox{X0: 30, Y0: 40, X1: 50, Y1: 60}, BBox{X0: 0, Y0: 0, X1: 10, Y1: 10}, BBox{X0: 0, Y0: 0, X1: 50, Y1: 60}},
+		{"empty first", BBox{}, BBox{X0: 5, Y0: 5, X1: 20, Y1: 15}, BBox{X0: 5, Y0: 5, X1: 20, Y1: 15}},
+		{"empty second", BBox{X0: 5, Y0: 5, X1: 20, Y1: 15}, BBox{X0: 100, Y0: 100, X1: 100, Y1: 120}, BBox{X0: 5, Y0: 5, X1: 20, Y1: 15}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Union(tt.b); got != tt.want {
+				t.Errorf("Union() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMeanConfidence(t *testing.T) {
+	doc := testDocument()
+
+	tests := []struct {
+		name   string
+		mean   func() (float64, bool)
+		want   float64
+		wantOK bool
+	}{
+		{"block", doc.Pages[0].Blocks[0].MeanConfidence, 85, true},
+		{"block without words", doc.Pages[0].Blocks[1].MeanConfidence, 0, false},
+		// the word without a confidence is skipped
+		{"page", doc.Pages[1].MeanConfidence, 40, true},
+		// every word weighs the same: (90 + 80 + 40) / 3
+		{"document", doc.MeanConfidence, 70, true},
+		{"empty document", (&Document{}).MeanConfidence, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.mean()
+			if ok != tt.wantOK || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("MeanConfidence() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+const DefaultTenant = "default"
+
+// Glossary holds the terminology of a tenant. Terms map a source term to the
+// translation it must get, DoNotTranslate terms are kept as they are.
+type Glossary struct {
+	ID             string            `json:"id"`
+	TenantID       string            `json:"tenant_id"`
+	Name           string            `json:"name"`
+	SourceLang     string            `json:"source_lang,omitempty"`
+	TargetLang     string            `json:"target_lang,omitempty"`
+	Terms          map[string]string `json:"terms"`
+	DoNotTranslate []string          `json:"do_not_translate"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
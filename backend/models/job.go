@@ -8,13 +8,93 @@ type Job struct {
 	ImagePath 	string
 	ImageDownloadURL	string
 	PDFUploadURL	string
+	// PDFUploadURLs holds one presigned upload URL per target language
+	PDFUploadURLs	map[string]string
+	// ResultUploadURLs holds a presigned upload URL per OCR result format
+	// (hocr, alto)
+	ResultUploadURLs	map[string]string
 	JobID		string
+	SourceLang	string
+	TargetLang	string
+	// TargetLangs lists every requested target language, the translate stage
+	// receives one copy of the job per language with TargetLang set to it
+	TargetLangs	[]string
+	DetectedLang	string
+	DetectedLangConfidence	float64
+	TenantID	string
+	GlossaryID	string
+	// OCRConfig holds the Tesseract settings requested on upload
+	OCRConfig	OCRConfig
+	// Preprocess lists the image cleanup steps run before OCR, see pkg/preprocess
+	Preprocess	[]string `json:"preprocess,omitempty"`
+	// Regions, if set, are recognized instead of the whole page
+	Regions	[]Region `json:"regions,omitempty"`
+	// Orientation is by how many degrees, clockwise, the image was turned
+	// upright before OCR and Script the writing system of its text.
+	// OrientationDetected is false unless the orient preprocessing step ran.
+	Orientation	int
+	OrientationDetected	bool
+	Script	string
+	// ExtractedText and TranslatedText are the plain text of Document, kept
+	// for messages queued before Document existed
 	ExtractedText string
 	TranslatedText string
+	// Document is the structured OCR result with per-block translations,
+	// nil in older messages
+	Document	*Document `json:"document,omitempty"`
+	// OCRConfidence is the mean word confidence (0-100) of Document and
+	// PageConfidences the mean per page; LowConfidence flags a job below
+	// the configured threshold
+	OCRConfidence	float64
+	PageConfidences	[]float64
+	LowConfidence	bool
+	// HighlightBelow, if set, highlights words with a lower confidence in the PDF
+	HighlightBelow	float64
+	// ProtectedSpans counts the URLs, emails, numbers and codes that were
+	// kept out of the translation to TargetLang, ProtectedSpansByLang those
+	// of every target language
+	ProtectedSpans	int
+	ProtectedSpansByLang	map[string]int `json:"protected_spans_by_lang,omitempty"`
 	OutFilePath	string
+	// OutputLayout is the PDF layout requested on upload, see pdf.Options
+	OutputLayout	string
+	// FailureReason is set by the stage that failed, later stages skip the job
+	FailureReason	string
 	SubmittedAt  time.Time `json:"submitted_at"`
 	CompletedAt  time.Time `json:"completed_at,omitempty"`
 	ResponseTime time.Duration `json:"-"`
 }
 
+// TargetLanguages returns the target languages of the job, jobs queued before
+// multi-language uploads only carry TargetLang
+func (job *Job) TargetLanguages() []string {
+	if len(job.TargetLangs) > 0 {
+		return job.TargetLangs
+	}
+	return []string{job.TargetLang}
+}
+
+// ForTargetLang returns a copy of the job that only translates to lang
+func (job *Job) ForTargetLang(lang string) Job {
+	copied := *job
+	copied.TargetLang = lang
+	copied.Document = job.Document.Clone()
+	if url, ok := job.PDFUploadURLs[lang]; ok {
+		copied.PDFUploadURL = url
+	}
+	return copied
+}
 
+// OutputName is the base name of the PDF (and other results) of the job for
+// its TargetLang: the job ID alone for single-language jobs, suffixed with
+// the language when the upload asked for several
+func (job *Job) OutputName() string {
+	return OutputName(job.JobID, job.TargetLang, len(job.TargetLanguages()) > 1)
+}
+
+func OutputName(jobID, lang string, multiLang bool) string {
+	if !multiLang || lang == "" {
+		return jobID
+	}
+	return jobID + "_" + lang
+}
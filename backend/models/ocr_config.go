@@ -0,0 +1,139 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ocrVariableName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	// a traineddata name such as "vie", "chi_sim" or "script/Latin"
+	ocrLanguageName = regexp.MustCompile(`^[A-Za-z0-9_]+(?:/[A-Za-z0-9_]+)?$`)
+)
+
+// OCRConfig holds the per-job Tesseract settings, the zero value keeps the
+// gosseract defaults
+type OCRConfig struct {
+	// Languages is a Tesseract language list such as "vie+eng"
+	Languages string `json:"languages,omitempty"`
+	// PageSegMode and EngineMode are Tesseract's --psm and --oem values,
+	// nil keeps the default
+	PageSegMode *int `json:"psm,omitempty"`
+	EngineMode  *int `json:"oem,omitempty"`
+	// Variables are passed to Tesseract's SetVariable, e.g.
+	// {"tessedit_char_whitelist": "0123456789"}
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// IsDefault reports whether the config changes nothing
+func (c OCRConfig) IsDefault() bool {
+	return c.Languages == "" && c.PageSegMode == nil && c.EngineMode == nil && len(c.Variables) == 0
+}
+
+// LanguageList splits Languages on "+"
+func (c OCRConfig) LanguageList() []string {
+	var langs []string
+	for _, lang := range strings.Split(c.Languages, "+") {
+		if lang = strings.TrimSpace(lang); lang != "" {
+			langs = append(langs, lang)
+		}
+	}
+	return langs
+}
+
+// Key is a canonical string of the config, equal configs give equal keys
+func (c OCRConfig) Key() string {
+	if c.IsDefault() {
+		return ""
+	}
+
+	parts := []string{"lang=" + strings.Join(c.LanguageList(), "+")}
+	if c.PageSegMode != nil {
+		parts = append(parts, fmt.Sprintf("psm=%d", *c.PageSegMode))
+	}
+	if c.EngineMode != nil {
+		parts = append(parts, fmt.Sprintf("oem=%d", *c.EngineMode))
+	}
+	names := make([]string, 0, len(c.Variables))
+	for name := range c.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, name+"="+c.Variables[name])
+	}
+	return strings.Join(parts, ";")
+}
+
+// ParseOCRConfig builds an OCRConfig from the upload form values. psm and
+// oem are integers, variables a JSON object of strings; empty values are
+// unset.
+func ParseOCRConfig(languages, psm, oem, variables string) (OCRConfig, error) {
+	cfg := OCRConfig{Languages: strings.Join(OCRConfig{Languages: languages}.LanguageList(), "+")}
+
+	if psm != "" {
+		mode, err := strconv.Atoi(psm)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid page segmentation mode: %s", psm)
+		}
+		cfg.PageSegMode = &mode
+	}
+	if oem != "" {
+		mode, err := strconv.Atoi(oem)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid OCR engine mode: %s", oem)
+		}
+		cfg.EngineMode = &mode
+	}
+	if variables != "" {
+		if err := json.Unmarshal([]byte(variables), &cfg.Variables); err != nil {
+			return cfg, fmt.Errorf("invalid tesseract variables: %w", err)
+		}
+	}
+	return cfg, cfg.Validate()
+}
+
+// Validate checks the modes and the language and variable names. Whether
+// the languages are installed is checked with CheckInstalled.
+func (c OCRConfig) Validate() error {
+	if c.PageSegMode != nil && (*c.PageSegMode < 0 || *c.PageSegMode > 13) {
+		return fmt.Errorf("page segmentation mode must be between 0 and 13, got %d", *c.PageSegMode)
+	}
+	if c.EngineMode != nil && (*c.EngineMode < 0 || *c.EngineMode > 3) {
+		return fmt.Errorf("OCR engine mode must be between 0 and 3, got %d", *c.EngineMode)
+	}
+	for _, lang := range c.LanguageList() {
+		if !ocrLanguageName.MatchString(lang) {
+			return fmt.Errorf("invalid tesseract language: %q", lang)
+		}
+	}
+	for name := range c.Variables {
+		if !ocrVariableName.MatchString(name) {
+			return fmt.Errorf("invalid tesseract variable name: %q", name)
+		}
+		// these make Tesseract write files on the worker host
+		if strings.Contains(name, "file") || strings.HasPrefix(name, "tessedit_write") || strings.HasPrefix(name, "debug") {
+			return fmt.Errorf("tesseract variable not allowed: %s", name)
+		}
+	}
+	return nil
+}
+
+// CheckInstalled fails unless every language of the config is one of
+// installed
+func (c OCRConfig) CheckInstalled(installed []string) error {
+	available := make(map[string]bool, len(installed))
+	for _, lang := range installed {
+		available[lang] = true
+	}
+	for _, lang := range c.LanguageList() {
+		if !available[lang] {
+			return fmt.Errorf("tesseract language not installed: %s", lang)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseOCRConfig(t *testing.T) {
+	tests := []struct {
+		name                           string
+		languages, psm, oem, variables string
+		wantKey                        string
+		wantErr                        string
+	}{
+		{name: "default", wantKey: ""},
+		{name: "languages", languages: " vie + eng ", wantKey: "lang=vie+eng"},
+		{name: "script", languages: "script/Latin", wantKey: "lang=script/Latin"},
+		{name: "modes", psm: "6", oem: "1", wantKey: "lang=;psm=6;oem=1"},
+		{
+			name:      "variables",
+			variables: `{"tessedit_char_whitelist": "0123456789", "classify_bln_numeric_mode": "1"}`,
+			wantKey:   "lang=;classify_bln_numeric_mode=1;tessedit_char_whitelist=0123456789",
+		},
+		{name: "psm not a number", psm: "six", wantErr: "invalid page segmentation mode"},
+		{name: "psm out of range", psm: "14", wantErr: "between 0 and 13"},
+		{name: "oem out of range", oem: "4", wantErr: "between 0 and 3"},
+		{name: "language path", languages: "../../etc/passwd", wantErr: "invalid tesseract language"},
+		{name: "variables not json", variables: `tessedit=1`, wantErr: "invalid tesseract variables"},
+		{name: "variable name", variables: `{"Bad-Name": "1"}`, wantErr: "invalid tesseract variable name"},
+		{name: "variable writing files", variables: `{"tessedit_write_images": "1"}`, wantErr: "not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseOCRConfig(tt.languages, tt.psm, tt.oem, tt.variables)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseOCRConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseOCRConfig() error = %v", err)
+			}
+			if key := cfg.Key(); key != tt.wantKey {
+				t.Errorf("Key() = %q, want %q", key, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestOCRConfigCheckInstalled(t *testing.T) {
+	installed := []string{"eng", "vie", "osd"}
+	tests := []struct {
+		name      string
+		languages string
+		wantErr   string
+	}{
+		{name: "default", languages: ""},
+		{name: "installed", languages: "vie+eng"},
+		{name: "missing", languages: "eng+jpn", wantErr: "tesseract language not installed: jpn"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseOCRConfig(tt.languages, "", "", "")
+			if err != nil {
+				t.Fatalf("ParseOCRConfig() error = %v", err)
+			}
+			err = cfg.CheckInstalled(installed)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CheckInstalled() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("CheckInstalled() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	RegionPixels   = "px"
+	RegionRelative = "relative"
+)
+
+// Region is a named rectangle of the page image, recognized instead of the
+// whole page. X, Y, Width and Height are pixels or, with Unit
+// RegionRelative, fractions of the image size.
+type Region struct {
+	Name   string  `json:"name"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	// Unit is RegionPixels (the default) or RegionRelative
+	Unit string `json:"unit,omitempty"`
+}
+
+// Bounds returns the region in the pixels of an image of width by height,
+// clipped to it; the box is empty if the region lies outside the image
+func (r Region) Bounds(width, height int) BBox {
+	x0, y0, x1, y1 := r.X, r.Y, r.X+r.Width, r.Y+r.Height
+	if r.Unit == RegionRelative {
+		x0, x1 = x0*float64(width), x1*float64(width)
+		y0, y1 = y0*float64(height), y1*float64(height)
+	}
+	box := BBox{
+		X0: max(int(math.Floor(x0)), 0),
+		Y0: max(int(math.Floor(y0)), 0),
+		X1: min(int(math.Ceil(x1)), width),
+		Y1: min(int(math.Ceil(y1)), height),
+	}
+	if box.Empty() {
+		return BBox{}
+	}
+	return box
+}
+
+// RegionsKey is a canonical string of the regions of a job, equal regions
+// give equal keys
+func RegionsKey(regions []Region) string {
+	parts := make([]string, len(regions))
+	for i, r := range regions {
+		unit := r.Unit
+		if unit == "" {
+			unit = RegionPixels
+		}
+		parts[i] = fmt.Sprintf("%s=%g,%g,%g,%g,%s", r.Name, r.X, r.Y, r.Width, r.Height, unit)
+	}
+	return strings.Join(parts, ";")
+}
+
+// RegionResult is the text recognized in a region and its translation by
+// target language
+type RegionResult struct {
+	Text         string            `json:"text"`
+	Translations map[string]string `json:"translations,omitempty"`
+}
+
+// RegionResults combines the texts of the regions, by name, with their
+// translations, by target language then name
+func RegionResults(texts map[string]string, translations map[string]map[string]string) map[string]RegionResult {
+	results := make(map[string]RegionResult, len(texts))
+	for name, text := range texts {
+		result := RegionResult{Text: text}
+		for lang, translated := range translations {
+			if value, ok := translated[name]; ok {
+				if result.Translations == nil {
+					result.Translations = map[string]string{}
+				}
+				result.Translations[lang] = value
+			}
+		}
+		results[name] = result
+	}
+	return results
+}
@@ -0,0 +1,52 @@
+package models
+
+import "testing"
+
+func TestRegionBounds(t *testing.T) {
+	tests := []struct {
+		name   string
+		region Region
+		want   BBox
+	}{
+		{"pixels", Region{X: 10, Y: 20, Width: 100, Height: 50}, BBox{X0: 10, Y0: 20, X1: 110, Y1: 70}},
+		{"fractional pixels", Region{X: 10.5, Y: 20.2, Width: 10, Height: 10}, BBox{X0: 10, Y0: 20, X1: 21, Y1: 31}},
+		{"relative", Region{X: 0.25, Y: 0.5, Width: 0.5, Height: 0.25, Unit: RegionRelative}, BBox{X0: 200, Y0: 300, X1: 600, Y1: 450}},
+		{"clipped", Region{X: 700, Y: 500, Width: 300, Height: 300}, BBox{X0: 700, Y0: 500, X1: 800, Y1: 600}},
+		{"outside", Region{X: 900, Y: 0, Width: 100, Height: 100}, BBox{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.region.Bounds(800, 600); got != tt.want {
+				t.Errorf("Bounds() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegionsKey(t *testing.T) {
+	address := Region{Name: "address", X: 120, Y: 80, Width: 600, Height: 200}
+	total := Region{Name: "total", X: 0.6, Y: 0.85, Width: 0.3, Height: 0.1, Unit: RegionRelative}
+
+	if got := RegionsKey(nil); got != "" {
+		t.Errorf("RegionsKey(nil) = %q, want empty", got)
+	}
+	if got, want := RegionsKey([]Region{address, total}), "address=120,80,600,200,px;total=0.6,0.85,0.3,0.1,relative"; got != want {
+		t.Errorf("RegionsKey() = %q, want %q", got, want)
+	}
+
+	// pixels are the default unit
+	explicit := address
+	explicit.Unit = RegionPixels
+	if RegionsKey([]Region{address}) != RegionsKey([]Region{explicit}) {
+		t.Error("RegionsKey() differs for the default and explicit pixel unit")
+	}
+	// the order of the regions is the order of the document blocks
+	if RegionsKey([]Region{address, total}) == RegionsKey([]Region{total, address}) {
+		t.Error("RegionsKey() ignores the order of the regions")
+	}
+	moved := address
+	moved.X++
+	if RegionsKey([]Region{address}) == RegionsKey([]Region{moved}) {
+		t.Error("RegionsKey() ignores the position of a region")
+	}
+}
@@ -2,18 +2,31 @@ package main
 
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 	"encoding/json"
+	"backend/pkg/export"
+	"backend/pkg/translation"
 	"backend/pkg/ocr"
+	"backend/pkg/ocr/tesseract"
+	"backend/pkg/redis"
+	"backend/pkg/tiff"
 	"backend/pkg/segmentation"
 	"backend/models"
 	"github.com/joho/godotenv"
 	"backend/pkg/aws_utils"
 	"backend/pkg/rabbitmq"
+	"github.com/redis/go-redis/v9"
 )
 
+var redisClient redis.Cmdable
+var redisCtx context.Context
+
 
 func main() {
 	// Load environment variables
@@ -22,12 +35,31 @@ func main() {
 		log.Fatal("Error loading .env file")
 	}
 
-	mode := "SPLIT_IMAGE"
+	// the OCR workers report the pages done of multi-page files, to the
+	// Redis the API server of the deployment uses
+	if os.Getenv("REDIS_CLUSTER") == "yes" {
+		redisClient, redisCtx = redis_utils.InitRedisCluster(false)
+	} else {
+		redisClient, redisCtx = redis_utils.InitRedis(false)
+	}
 
-	if mode == "CLIENT_POOL" {
-		ocr.Initialize()
-		defer ocr.Cleanup()
+	// the API servers reject uploads in languages no worker has installed
+	langs, err := tesseract.InstalledLanguages()
+	if err == nil {
+		err = redis_utils.PublishOCRLanguages(redisClient, redisCtx, langs)
+	}
+	if err != nil {
+		log.Printf("Failed to publish the installed OCR languages: %v", err)
 	}
+
+	// the segments of an image share the pooled clients
+	tesseract.Initialize()
+	defer tesseract.Cleanup()
+	engine := tesseract.NewEngine(true)
+
+	// stop recognizing the segments of the current image on shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	
 	conn, err := rabbitmq_utils.ConnectRabbitMQ()
 	rabbitmq_utils.FailOnError(err, "Failed to connect to RabbitMQ")
@@ -52,51 +84,138 @@ func main() {
 
 	var req_count int = 0
 
-	var forever chan struct{}
+	// done is closed once the consumer stopped
+	done := make(chan struct{})
 
 	go func() {
+		defer close(done)
 		for d := range msgs {
 			start_time := time.Now()
 			var job models.Job
 			err := json.Unmarshal(d.Body, &job)
 			rabbitmq_utils.FailOnError(err, "Failed to unmarshal job")
 
-			err = processMessage(&job, mode)
+			err = processMessage(ctx, engine, &job)
+			if ctx.Err() != nil {
+				// leave the job to another worker
+				d.Nack(false, true)
+				return
+			}
 			if err != nil {
 				log.Printf("Failed to process image: %v", err)
+				// the translate worker records the failure instead of translating
+				job.FailureReason = err.Error()
 			}
 
-			new_msg, err := json.Marshal(job)
-			rabbitmq_utils.FailOnError(err, "Failed to marshal job")
+			// fan out: one translation message per target language
+			for _, lang := range job.TargetLanguages() {
+				new_msg, err := json.Marshal(job.ForTargetLang(lang))
+				rabbitmq_utils.FailOnError(err, "Failed to marshal job")
+				rabbitmq_utils.PublishMessage(channel, "translation-queue", new_msg)
+			}
 			req_count++
 			log.Printf("Processed %dth requests", req_count)
-			rabbitmq_utils.PublishMessage(channel, "translation-queue", new_msg)
 			log.Printf("OCR job completed in %v", time.Since(start_time))
 			d.Ack(false)
 		}
 	}()
 
 	log.Printf(" [*] Waiting for messages. To exit press CTRL+C")
-	<-forever
+	select {
+	case <-ctx.Done():
+	case <-done:
+		log.Printf("RabbitMQ closed the deliveries")
+	}
+	// a second signal kills the worker
+	stop()
+	log.Printf("Shutting down")
+	// closing the channel ends the deliveries; the job in progress stops at
+	// its next segment and, never acked, is requeued by RabbitMQ
+	channel.Close()
+	conn.Close()
+	// the clients are closed by the deferred tesseract.Cleanup once the
+	// consumer no longer uses them
+	<-done
 }
 
 
-func processMessage(job *models.Job, mode string) error {
+func processMessage(ctx context.Context, engine ocr.OCREngine, job *models.Job) error {
 
 	var err error
-	var text string
-	if job.ImageDownloadURL == "" {
+	var doc *models.Document
+	if job.ImageDownloadURL != "" {
 		err = aws_utils.DownloadFile(job.ImageDownloadURL, job.ImagePath)
+		if err != nil {
+			return fmt.Errorf("failed to download image: %w", err)
+		}
 	}
 
-	segmentPaths := segmentation.SplitImage(job.ImagePath, job.JobID)
-	text, err = ocr.OCRFilterConcurrent(segmentPaths)
+	if len(job.Regions) > 0 {
+		// only the regions of interest are recognized, not segmented
+		doc, err = ocr.OCRRegions(ctx, engine, job.ImagePath, job.Regions, job.Preprocess, job.OCRConfig, job.JobID)
+		if err != nil {
+			return fmt.Errorf("failed to process regions: %w", err)
+		}
+		pageDone(job.JobID)()
+	} else if tiff.IsTIFF(job.ImagePath) {
+		// segmentation decodes the image, TIFF pages are recognized whole
+		doc, err = ocr.OCRTIFF(ctx, engine, job.ImagePath, job.Preprocess, job.OCRConfig, job.JobID, pageDone(job.JobID))
+		if err != nil {
+			return fmt.Errorf("failed to process image: %w", err)
+		}
+		job.Orientation = doc.Pages[0].Orientation
+		job.OrientationDetected = doc.Pages[0].OrientationDetected
+	} else {
+		prepared, err := ocr.PrepareImage(engine, job.ImagePath, job.Preprocess, job.OCRConfig, job.JobID)
+		if err != nil {
+			return fmt.Errorf("failed to preprocess image: %w", err)
+		}
+		defer prepared.Cleanup()
+		job.Orientation = prepared.Orientation
+		job.OrientationDetected = prepared.OrientationDetected
+
+		segmentPaths := segmentation.SplitImage(prepared.Path, job.JobID)
+		doc, err = ocr.OCRDocumentConcurrent(ctx, engine, segmentPaths, job.OCRConfig)
 
+		if err != nil {
+			return fmt.Errorf("failed to process image: %w", err)
+		}
+		// the segments cut across tables, they are found on the whole image
+		err = ocr.RecognizeTables(ctx, engine, prepared.Path, &doc.Pages[0], job.OCRConfig)
+		if err != nil {
+			log.Printf("Failed to recognize the tables of job %s: %v", job.JobID, err)
+		}
+		prepared.Unmap(doc)
+		pageDone(job.JobID)()
+	}
+	job.Document = doc
+	job.ExtractedText = doc.Text()
+	job.Script = ocr.DetectScript(job.ExtractedText)
+	ocr.ScoreJob(job)
+	if job.LowConfidence {
+		log.Printf("Job %s has a low OCR confidence: %.1f", job.JobID, job.OCRConfidence)
+	}
+
+	// the hOCR and ALTO results are stored next to the PDF, the
+	// translation does not depend on them
+	lang := job.SourceLang
+	if lang == translation.AutoDetect {
+		lang = ""
+	}
+	err = export.SaveOCRResults(job, lang)
 	if err != nil {
-		return fmt.Errorf("failed to process image: %w", err)
+		log.Printf("Failed to save the OCR results of job %s: %v", job.JobID, err)
 	}
-	job.ExtractedText = text
 	return nil
 }
 
 
+// pageDone returns the callback counting a recognized page in the job status
+func pageDone(jobID string) func() {
+	return func() {
+		err := redis_utils.PageDone(redisClient, redisCtx, jobID)
+		if err != nil {
+			log.Printf("Failed to update page progress: %v", err)
+		}
+	}
+}
@@ -5,13 +5,24 @@ import (
 	"fmt"
 	"log"
 	"encoding/json"
+	"context"
+	"os"
+	"backend/pkg/export"
 	"backend/pkg/ocr"
+	"backend/pkg/ocr/tesseract"
+	"backend/pkg/redis"
+	"backend/pkg/tiff"
+	"backend/pkg/translation"
 	"backend/models"
 	"github.com/joho/godotenv"
 	"backend/pkg/aws_utils"
 	"backend/pkg/rabbitmq"
+	"github.com/redis/go-redis/v9"
 )
 
+var redisClient redis.Cmdable
+var redisCtx context.Context
+
 
 func main() {
 	// Load environment variables
@@ -22,10 +33,28 @@ func main() {
 
 	mode := "CLIENT_POOL"
 
+	// the OCR workers report the pages done of multi-page files, to the
+	// Redis the API server of the deployment uses
+	if os.Getenv("REDIS_CLUSTER") == "yes" {
+		redisClient, redisCtx = redis_utils.InitRedisCluster(false)
+	} else {
+		redisClient, redisCtx = redis_utils.InitRedis(false)
+	}
+
+	// the API servers reject uploads in languages no worker has installed
+	langs, err := tesseract.InstalledLanguages()
+	if err == nil {
+		err = redis_utils.PublishOCRLanguages(redisClient, redisCtx, langs)
+	}
+	if err != nil {
+		log.Printf("Failed to publish the installed OCR languages: %v", err)
+	}
+
 	if mode == "CLIENT_POOL" {
-		ocr.Initialize()
-		defer ocr.Cleanup()
+		tesseract.Initialize()
+		defer tesseract.Cleanup()
 	}
+	engine := tesseract.NewEngine(mode == "CLIENT_POOL")
 	
 	conn, err := rabbitmq_utils.ConnectRabbitMQ()
 	rabbitmq_utils.FailOnError(err, "Failed to connect to RabbitMQ")
@@ -58,16 +87,21 @@ func main() {
 			err := json.Unmarshal(d.Body, &job)
 			rabbitmq_utils.FailOnError(err, "Failed to unmarshal job")
 
-			err = processMessage(&job, mode)
+			err = processMessage(engine, &job)
 			if err != nil {
 				log.Printf("Failed to process image: %v", err)
+				// the translate worker records the failure instead of translating
+				job.FailureReason = err.Error()
 			}
 
-			new_msg, err := json.Marshal(job)
-			rabbitmq_utils.FailOnError(err, "Failed to marshal job")
+			// fan out: one translation message per target language
+			for _, lang := range job.TargetLanguages() {
+				new_msg, err := json.Marshal(job.ForTargetLang(lang))
+				rabbitmq_utils.FailOnError(err, "Failed to marshal job")
+				rabbitmq_utils.PublishMessage(channel, "translation-queue", new_msg)
+			}
 			req_count++
 			log.Printf("Processed %dth requests", req_count)
-			rabbitmq_utils.PublishMessage(channel, "translation-queue", new_msg)
 			d.Ack(false)
 		}
 	}()
@@ -77,25 +111,81 @@ func main() {
 }
 
 
-func processMessage(job *models.Job, mode string) error {
+func processMessage(engine ocr.OCREngine, job *models.Job) error {
 
-	var text string
+	var doc *models.Document
 	var err error
 
 	if job.ImageDownloadURL != "" {
 		err = aws_utils.DownloadFile(job.ImageDownloadURL, job.ImagePath)
+		if err != nil {
+			return fmt.Errorf("failed to download image: %w", err)
+		}
 	}
 
-	if mode == "CLIENT_POOL" {
-		text, err = ocr.OCRFilter(job.ImagePath)
+	if len(job.Regions) > 0 {
+		// only the regions of interest are recognized
+		doc, err = ocr.OCRRegions(context.Background(), engine, job.ImagePath, job.Regions, job.Preprocess, job.OCRConfig, job.JobID)
+		if err != nil {
+			return fmt.Errorf("failed to process regions: %w", err)
+		}
+		pageDone(job.JobID)()
+	} else if tiff.IsTIFF(job.ImagePath) {
+		// the pages of a scan are recognized in parallel
+		doc, err = ocr.OCRTIFF(context.Background(), engine, job.ImagePath, job.Preprocess, job.OCRConfig, job.JobID, pageDone(job.JobID))
+		if err != nil {
+			return fmt.Errorf("failed to process image: %w", err)
+		}
+		job.Orientation = doc.Pages[0].Orientation
+		job.OrientationDetected = doc.Pages[0].OrientationDetected
 	} else {
-		text, err = ocr.OneShotOCR(job.ImagePath)
+		prepared, err := ocr.PrepareImage(engine, job.ImagePath, job.Preprocess, job.OCRConfig, job.JobID)
+		if err != nil {
+			return fmt.Errorf("failed to preprocess image: %w", err)
+		}
+		defer prepared.Cleanup()
+		job.Orientation = prepared.Orientation
+		job.OrientationDetected = prepared.OrientationDetected
+
+		doc, err = engine.Recognize(prepared.Path, job.OCRConfig)
+		if err != nil {
+			return fmt.Errorf("failed to process image: %w", err)
+		}
+		err = ocr.RecognizeTables(context.Background(), engine, prepared.Path, &doc.Pages[0], job.OCRConfig)
+		if err != nil {
+			log.Printf("Failed to recognize the tables of job %s: %v", job.JobID, err)
+		}
+		prepared.Unmap(doc)
+		pageDone(job.JobID)()
+	}
+	job.Document = doc
+	job.ExtractedText = doc.Text()
+	job.Script = ocr.DetectScript(job.ExtractedText)
+	ocr.ScoreJob(job)
+	if job.LowConfidence {
+		log.Printf("Job %s has a low OCR confidence: %.1f", job.JobID, job.OCRConfidence)
 	}
 
+	// the hOCR and ALTO results are stored next to the PDF, the
+	// translation does not depend on them
+	lang := job.SourceLang
+	if lang == translation.AutoDetect {
+		lang = ""
+	}
+	err = export.SaveOCRResults(job, lang)
 	if err != nil {
-		return fmt.Errorf("failed to process image: %w", err)
+		log.Printf("Failed to save the OCR results of job %s: %v", job.JobID, err)
 	}
-	job.ExtractedText = text
 	return nil
 }
 
+
+// pageDone returns the callback counting a recognized page in the job status
+func pageDone(jobID string) func() {
+	return func() {
+		err := redis_utils.PageDone(redisClient, redisCtx, jobID)
+		if err != nil {
+			log.Printf("Failed to update page progress: %v", err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package export
+
+import (
+	"backend/models"
+	"encoding/xml"
+	"fmt"
+)
+
+const (
+	altoNamespace      = "http://www.loc.gov/standards/alto/ns-v4#"
+	altoSchemaLocation = altoNamespace + " http://www.loc.gov/alto/v4/alto-4-2.xsd"
+)
+
+type altoDocument struct {
+	XMLName        xml.Name        `xml:"alto"`
+	Namespace      string          `xml:"xmlns,attr"`
+	XSI            string          `xml:"xmlns:xsi,attr"`
+	SchemaLocation string          `xml:"xsi:schemaLocation,attr"`
+	Description    altoDescription `xml:"Description"`
+	Pages          []altoPage      `xml:"Layout>Page"`
+}
+
+type altoDescription struct {
+	MeasurementUnit string `xml:"MeasurementUnit"`
+	Software        string `xml:"OCRProcessing>ocrProcessingStep>processingSoftware>softwareName"`
+}
+
+// altoBox holds the position attributes shared by every ALTO element
+type altoBox struct {
+	HPos   int `xml:"HPOS,attr"`
+	VPos   int `xml:"VPOS,attr"`
+	Width  int `xml:"WIDTH,attr"`
+	Height int `xml:"HEIGHT,attr"`
+}
+
+type altoPage struct {
+	ID         string         `xml:"ID,attr"`
+	PhysicalNr int            `xml:"PHYSICAL_IMG_NR,attr"`
+	Width      int            `xml:"WIDTH,attr"`
+	Height     int            `xml:"HEIGHT,attr"`
+	PrintSpace altoPrintSpace `xml:"PrintSpace"`
+}
+
+type altoPrintSpace struct {
+	altoBox
+	Blocks []altoTextBlock `xml:"TextBlock"`
+}
+
+type altoTextBlock struct {
+	ID   string `xml:"ID,attr"`
+	Lang string `xml:"LANG,attr,omitempty"`
+	altoBox
+	Lines []altoTextLine `xml:"TextLine"`
+}
+
+type altoTextLine struct {
+	ID string `xml:"ID,attr"`
+	altoBox
+	// Strings alternates String and SP elements
+	Strings []interface{}
+}
+
+type altoString struct {
+	XMLName xml.Name `xml:"String"`
+	ID      string   `xml:"ID,attr"`
+	altoBox
+	WC      string `xml:"WC,attr"`
+	Content string `xml:"CONTENT,attr"`
+}
+
+type altoSpace struct {
+	XMLName xml.Name `xml:"SP"`
+}
+
+func newAltoBox(box models.BBox) altoBox {
+	return altoBox{HPos: box.X0, VPos: box.Y0, Width: box.Width(), Height: box.Height()}
+}
+
+// ALTO encodes doc as an ALTO v4 document, word confidences become WC in 0..1
+func ALTO(doc *models.Document, lang string) ([]byte, error) {
+	alto := altoDocument{
+		Namespace:      altoNamespace,
+		XSI:            "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation: altoSchemaLocation,
+		Description: altoDescription{
+			MeasurementUnit: "pixel",
+			Software:        "tesseract",
+		},
+	}
+
+	for _, page := range doc.Pages {
+		var printSpace models.BBox
+		altoBlocks := make([]altoTextBlock, 0, len(page.Blocks))
+		for b, block := range page.Blocks {
+			printSpace = printSpace.Union(block.BBox)
+			id := fmt.Sprintf("%d_%d", page.Number, b+1)
+			altoBlock := altoTextBlock{ID: "block_" + id, Lang: lang, altoBox: newAltoBox(block.BBox)}
+
+			for l, line := range block.Lines {
+				lineID := fmt.Sprintf("%s_%d", id, l+1)
+				altoLine := altoTextLine{ID: "line_" + lineID, altoBox: newAltoBox(line.BBox)}
+				for w, word := range line.Words {
+					if w > 0 {
+						altoLine.Strings = append(altoLine.Strings, altoSpace{})
+					}
+					altoLine.Strings = append(altoLine.Strings, altoString{
+						ID:      fmt.Sprintf("word_%s_%d", lineID, w+1),
+						altoBox: newAltoBox(word.BBox),
+						WC:      fmt.Sprintf("%.2f", word.Confidence/100),
+						Content: word.Text,
+					})
+				}
+				altoBlock.Lines = append(altoBlock.Lines, altoLine)
+			}
+			altoBlocks = append(altoBlocks, altoBlock)
+		}
+
+		width, height := page.Width, page.Height
+		if width == 0 || height == 0 {
+			width, height = printSpace.X1, printSpace.Y1
+		}
+		alto.Pages = append(alto.Pages, altoPage{
+			ID:         fmt.Sprintf("page_%d", page.Number),
+			PhysicalNr: page.Number,
+			Width:      width,
+			Height:     height,
+			PrintSpace: altoPrintSpace{altoBox: newAltoBox(printSpace), Blocks: altoBlocks},
+		})
+	}
+
+	body, err := xml.MarshalIndent(alto, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode ALTO: %w", err)
+	}
+	return append([]byte(xml.Header), append(body, '\n')...), nil
+}
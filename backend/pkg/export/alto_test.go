@@ -0,0 +1,95 @@
+package export
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+// altoResult reads back the parts of an ALTO document the tests check
+type altoResult struct {
+	Pages []struct {
+		ID         string `xml:"ID,attr"`
+		Width      int    `xml:"WIDTH,attr"`
+		Height     int    `xml:"HEIGHT,attr"`
+		PrintSpace struct {
+			altoBox
+			Blocks []struct {
+				ID    string `xml:"ID,attr"`
+				Lang  string `xml:"LANG,attr"`
+				Lines []struct {
+					ID      string `xml:"ID,attr"`
+					Strings []struct {
+						altoBox
+						WC      string `xml:"WC,attr"`
+						Content string `xml:"CONTENT,attr"`
+					} `xml:"String"`
+					Spaces []struct{} `xml:"SP"`
+				} `xml:"TextLine"`
+			} `xml:"TextBlock"`
+		} `xml:"PrintSpace"`
+	} `xml:"Layout>Page"`
+}
+
+func TestALTO(t *testing.T) {
+	out, err := ALTO(testDocument(), "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var alto altoResult
+	if err := xml.Unmarshal(out, &alto); err != nil {
+		t.Fatalf("ALTO is not well-formed: %v\n%s", err, out)
+	}
+	if len(alto.Pages) != 1 {
+		t.Fatalf("%d pages, want 1", len(alto.Pages))
+	}
+	page := alto.Pages[0]
+	if page.ID != "page_1" || page.Width != 800 || page.Height != 600 {
+		t.Errorf("page = %s %dx%d, want page_1 800x600", page.ID, page.Width, page.Height)
+	}
+	if want := (altoBox{HPos: 10, VPos: 10, Width: 290, Height: 110}); page.PrintSpace.altoBox != want {
+		t.Errorf("print space = %+v, want %+v", page.PrintSpace.altoBox, want)
+	}
+
+	blocks := page.PrintSpace.Blocks
+	if len(blocks) != 2 || blocks[0].ID != "block_1_1" || blocks[0].Lang != "en" || len(blocks[0].Lines) != 2 {
+		t.Fatalf("blocks = %+v", blocks)
+	}
+	line := blocks[0].Lines[0]
+	if line.ID != "line_1_1_1" || len(line.Spaces) != 1 {
+		t.Errorf("line %s has %d spaces, want line_1_1_1 with 1", line.ID, len(line.Spaces))
+	}
+
+	tests := []struct {
+		content string
+		wc      string
+		box     altoBox
+	}{
+		{"Hello", "0.96", altoBox{HPos: 10, VPos: 10, Width: 90, Height: 20}},
+		{"<world>", "0.89", altoBox{HPos: 110, VPos: 10, Width: 190, Height: 20}},
+	}
+	for i, tt := range tests {
+		word := line.Strings[i]
+		if word.Content != tt.content || word.WC != tt.wc || word.altoBox != tt.box {
+			t.Errorf("word %d = %q WC %s %+v, want %q WC %s %+v", i, word.Content, word.WC, word.altoBox, tt.content, tt.wc, tt.box)
+		}
+	}
+}
+
+func TestALTOPageWithoutSize(t *testing.T) {
+	doc := testDocument()
+	doc.Pages[0].Width, doc.Pages[0].Height = 0, 0
+	out, err := ALTO(doc, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var alto altoResult
+	if err := xml.Unmarshal(out, &alto); err != nil {
+		t.Fatal(err)
+	}
+	if page := alto.Pages[0]; page.Width != 300 || page.Height != 120 {
+		t.Errorf("page size = %dx%d, want the extent of the blocks 300x120", page.Width, page.Height)
+	}
+	if lang := alto.Pages[0].PrintSpace.Blocks[0].Lang; lang != "" {
+		t.Errorf("LANG = %q, want none", lang)
+	}
+}
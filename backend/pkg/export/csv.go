@@ -0,0 +1,26 @@
+package export
+
+import (
+	"backend/models"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+)
+
+// CSV writes the recognized cells of the tables of doc, in reading order,
+// the tables separated by an empty line. A document without tables gives an
+// empty file.
+func CSV(doc *models.Document) ([]byte, error) {
+	var buf bytes.Buffer
+	for i, table := range doc.Tables() {
+		if i > 0 {
+			buf.WriteString("\r\n")
+		}
+		writer := csv.NewWriter(&buf)
+		writer.UseCRLF = true
+		if err := writer.WriteAll(table.Cells()); err != nil {
+			return nil, fmt.Errorf("failed to write table %d: %w", i+1, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
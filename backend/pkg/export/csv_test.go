@@ -0,0 +1,79 @@
+package export
+
+import (
+	"backend/models"
+	"testing"
+)
+
+// tableBlock is a block holding a table of the cell texts
+func tableBlock(rows ...[]string) models.Block {
+	table := &models.Table{}
+	for _, row := range rows {
+		cells := make([]models.Cell, len(row))
+		for c, text := range row {
+			cells[c] = models.Cell{Text: text}
+		}
+		table.Rows = append(table.Rows, cells)
+	}
+	return models.Block{Table: table, Text: table.Text()}
+}
+
+func TestCSV(t *testing.T) {
+	translated := tableBlock([]string{"Item", "Price"}, []string{"Tea", "4.50"})
+	for _, row := range translated.Table.Rows {
+		for c := range row {
+			row[c].TranslatedText = "dịch " + row[c].Text
+		}
+	}
+
+	tests := []struct {
+		name   string
+		blocks []models.Block
+		// blocks of a second page, if any
+		next []models.Block
+		want string
+	}{
+		{name: "no tables", blocks: testDocument().Pages[0].Blocks, want: ""},
+		{
+			name:   "one table",
+			blocks: []models.Block{{Text: "Invoice"}, tableBlock([]string{"Item", "Price"}, []string{"Tea", "4.50"})},
+			want:   "Item,Price\r\nTea,4.50\r\n",
+		},
+		{
+			name:   "quoted cells",
+			blocks: []models.Block{tableBlock([]string{"1,000", `say "hi"`}, []string{"two\nlines", ""})},
+			want:   "\"1,000\",\"say \"\"hi\"\"\"\r\n\"two\r\nlines\",\r\n",
+		},
+		{
+			name:   "two tables",
+			blocks: []models.Block{tableBlock([]string{"a", "b"}), {Text: "between"}, tableBlock([]string{"c"}, []string{"d"})},
+			want:   "a,b\r\n\r\nc\r\nd\r\n",
+		},
+		{
+			name:   "recognized text of translated cells",
+			blocks: []models.Block{translated},
+			want:   "Item,Price\r\nTea,4.50\r\n",
+		},
+		{
+			name:   "tables on two pages",
+			blocks: []models.Block{tableBlock([]string{"page 1"})},
+			next:   []models.Block{{Text: "Totals"}, tableBlock([]string{"Sum", "4.50"})},
+			want:   "page 1\r\n\r\nSum,4.50\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &models.Document{Pages: []models.Page{{Number: 1, Blocks: tt.blocks}}}
+			if tt.next != nil {
+				doc.Pages = append(doc.Pages, models.Page{Number: 2, Blocks: tt.next})
+			}
+			got, err := CSV(doc)
+			if err != nil {
+				t.Fatalf("CSV() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("CSV() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package export
+
+import (
+	"backend/models"
+	"backend/pkg/aws_utils"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	FormatPDF  = "pdf"
+	FormatHOCR = "hocr"
+	FormatALTO = "alto"
+	// FormatCSV holds the tables of the document
+	FormatCSV = "csv"
+)
+
+// OCRFormats are the formats written for every job next to its PDF
+var OCRFormats = []string{FormatHOCR, FormatALTO, FormatCSV}
+
+// Extension returns the file name suffix of a result format
+func Extension(format string) string {
+	switch format {
+	case FormatHOCR:
+		return ".hocr"
+	case FormatALTO:
+		return ".alto.xml"
+	case FormatCSV:
+		return ".csv"
+	default:
+		return ".pdf"
+	}
+}
+
+// ValidateFormat checks a result-format parameter, empty means FormatPDF
+func ValidateFormat(format string) error {
+	switch format {
+	case "", FormatPDF, FormatHOCR, FormatALTO, FormatCSV:
+		return nil
+	default:
+		return fmt.Errorf("unsupported result format: %s", format)
+	}
+}
+
+// Render encodes doc in one of OCRFormats. lang is the language of the text,
+// it may be empty.
+func Render(doc *models.Document, format, lang string) ([]byte, error) {
+	switch format {
+	case FormatHOCR:
+		return HOCR(doc, lang), nil
+	case FormatALTO:
+		return ALTO(doc, lang)
+	case FormatCSV:
+		return CSV(doc)
+	default:
+		return nil, fmt.Errorf("unsupported OCR format: %s", format)
+	}
+}
+
+// SaveOCRResults writes the OCRFormats of the job's document, to S3 through
+// job.ResultUploadURLs when the upload provided them and to ./output otherwise.
+// A format that fails does not stop the others, every failure is reported in
+// the joined error.
+func SaveOCRResults(job *models.Job, lang string) error {
+	var failures []error
+	for _, format := range OCRFormats {
+		data, err := Render(job.Document, format, lang)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("failed to render %s: %w", format, err))
+			continue
+		}
+
+		if uploadURL := job.ResultUploadURLs[format]; uploadURL != "" {
+			err = aws_utils.UploadStream(bytes.NewReader(data), uploadURL)
+		} else {
+			err = os.WriteFile("./output/"+job.JobID+Extension(format), data, 0644)
+		}
+		if err != nil {
+			failures = append(failures, fmt.Errorf("failed to save %s: %w", format, err))
+		}
+	}
+	return errors.Join(failures...)
+}
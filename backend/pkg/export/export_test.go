@@ -0,0 +1,100 @@
+package export
+
+import (
+	"backend/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testDocument has a page of two paragraphs, the first of two lines
+func testDocument() *models.Document {
+	word := func(text string, x0, y0, x1, y1 int, confidence float64) models.Word {
+		return models.Word{Text: text, BBox: models.BBox{X0: x0, Y0: y0, X1: x1, Y1: y1}, Confidence: confidence}
+	}
+	return &models.Document{Pages: []models.Page{{
+		Number: 1,
+		Width:  800,
+		Height: 600,
+		Blocks: []models.Block{
+			{
+				BBox: models.BBox{X0: 10, Y0: 10, X1: 300, Y1: 60},
+				Text: "Hello <world> & co",
+				Lines: []models.Line{
+					{BBox: models.BBox{X0: 10, Y0: 10, X1: 300, Y1: 30}, Words: []models.Word{
+						word("Hello", 10, 10, 100, 30, 96.4), word("<world>", 110, 10, 300, 30, 88.6),
+					}},
+					{BBox: models.BBox{X0: 10, Y0: 40, X1: 120, Y1: 60}, Words: []models.Word{
+						word("&", 10, 40, 30, 60, 70), word("co", 40, 40, 120, 60, 55),
+					}},
+				},
+			},
+			{
+				BBox: models.BBox{X0: 10, Y0: 100, X1: 200, Y1: 120},
+				Text: "Bye",
+				Lines: []models.Line{
+					{BBox: models.BBox{X0: 10, Y0: 100, X1: 200, Y1: 120}, Words: []models.Word{word("Bye", 10, 100, 200, 120, 100)}},
+				},
+			},
+		},
+	}}}
+}
+
+func TestValidateFormat(t *testing.T) {
+	tests := []struct {
+		format    string
+		extension string
+		wantErr   bool
+	}{
+		{"", ".pdf", false},
+		{FormatPDF, ".pdf", false},
+		{FormatHOCR, ".hocr", false},
+		{FormatALTO, ".alto.xml", false},
+		{FormatCSV, ".csv", false},
+		{"docx", "", true},
+		{"../secret", "", true},
+	}
+	for _, tt := range tests {
+		err := ValidateFormat(tt.format)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateFormat(%q) error = %v, wantErr %v", tt.format, err, tt.wantErr)
+		}
+		if err == nil && Extension(tt.format) != tt.extension {
+			t.Errorf("Extension(%q) = %q, want %q", tt.format, Extension(tt.format), tt.extension)
+		}
+	}
+}
+
+func TestSaveOCRResults(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	job := &models.Job{JobID: "job", Document: testDocument()}
+	// without ./output every format fails, each is reported
+	err = SaveOCRResults(job, "en")
+	for _, format := range OCRFormats {
+		if err == nil || !strings.Contains(err.Error(), "failed to save "+format) {
+			t.Errorf("SaveOCRResults() error = %v, want %s reported", err, format)
+		}
+	}
+
+	if err := os.Mkdir("output", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveOCRResults(job, "en"); err != nil {
+		t.Fatalf("SaveOCRResults() error = %v", err)
+	}
+	for _, format := range OCRFormats {
+		if _, err := os.Stat(filepath.Join("output", "job"+Extension(format))); err != nil {
+			t.Errorf("%s result missing: %v", format, err)
+		}
+	}
+}
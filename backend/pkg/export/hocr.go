@@ -0,0 +1,69 @@
+package export
+
+import (
+	"backend/models"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// HOCR encodes doc as an hOCR 1.2 document. Each block becomes an ocr_carea
+// holding one ocr_par, as the document keeps paragraphs only.
+func HOCR(doc *models.Document, lang string) []byte {
+	var out strings.Builder
+	out.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+`)
+	if lang != "" {
+		fmt.Fprintf(&out, "<html xmlns=\"http://www.w3.org/1999/xhtml\" xml:lang=\"%s\" lang=\"%s\">\n", html.EscapeString(lang), html.EscapeString(lang))
+	} else {
+		out.WriteString("<html xmlns=\"http://www.w3.org/1999/xhtml\">\n")
+	}
+	out.WriteString(` <head>
+  <title></title>
+  <meta http-equiv="Content-Type" content="text/html;charset=utf-8"/>
+  <meta name="ocr-system" content="tesseract"/>
+  <meta name="ocr-capabilities" content="ocr_page ocr_carea ocr_par ocr_line ocrx_word ocrp_wconf"/>
+ </head>
+ <body>
+`)
+
+	for p, page := range doc.Pages {
+		pageBox := models.BBox{X1: page.Width, Y1: page.Height}
+		if pageBox.Empty() {
+			for _, block := range page.Blocks {
+				pageBox = pageBox.Union(block.BBox)
+			}
+		}
+		fmt.Fprintf(&out, "  <div class=\"ocr_page\" id=\"page_%d\" title=\"%s; ppageno %d\">\n", page.Number, bboxTitle(pageBox), p)
+
+		for b, block := range page.Blocks {
+			id := fmt.Sprintf("%d_%d", page.Number, b+1)
+			fmt.Fprintf(&out, "   <div class=\"ocr_carea\" id=\"block_%s\" title=\"%s\">\n", id, bboxTitle(block.BBox))
+			fmt.Fprintf(&out, "    <p class=\"ocr_par\" id=\"par_%s\" title=\"%s\">\n", id, bboxTitle(block.BBox))
+
+			for l, line := range block.Lines {
+				lineID := fmt.Sprintf("%s_%d", id, l+1)
+				fmt.Fprintf(&out, "     <span class=\"ocr_line\" id=\"line_%s\" title=\"%s\">", lineID, bboxTitle(line.BBox))
+				for w, word := range line.Words {
+					if w > 0 {
+						out.WriteString(" ")
+					}
+					fmt.Fprintf(&out, "<span class=\"ocrx_word\" id=\"word_%s_%d\" title=\"%s; x_wconf %d\">%s</span>",
+						lineID, w+1, bboxTitle(word.BBox), int(word.Confidence+0.5), html.EscapeString(word.Text))
+				}
+				out.WriteString("</span>\n")
+			}
+
+			out.WriteString("    </p>\n   </div>\n")
+		}
+		out.WriteString("  </div>\n")
+	}
+
+	out.WriteString(" </body>\n</html>\n")
+	return []byte(out.String())
+}
+
+func bboxTitle(box models.BBox) string {
+	return fmt.Sprintf("bbox %d %d %d %d", box.X0, box.Y0, box.X1, box.Y1)
+}
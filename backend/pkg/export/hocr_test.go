@@ -0,0 +1,94 @@
+package export
+
+import (
+	"backend/models"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+// hocrSpan is an element of an hOCR document with its class and title
+type hocrSpan struct {
+	Class    string     `xml:"class,attr"`
+	ID       string     `xml:"id,attr"`
+	Title    string     `xml:"title,attr"`
+	Text     string     `xml:",chardata"`
+	Children []hocrSpan `xml:",any"`
+}
+
+// collect returns the elements of class below s, in document order
+func (s hocrSpan) collect(class string) []hocrSpan {
+	var found []hocrSpan
+	for _, child := range s.Children {
+		if child.Class == class {
+			found = append(found, child)
+		}
+		found = append(found, child.collect(class)...)
+	}
+	return found
+}
+
+func TestHOCR(t *testing.T) {
+	out := HOCR(testDocument(), "en")
+	if !strings.Contains(string(out), `xml:lang="en"`) {
+		t.Error("hOCR misses the language")
+	}
+
+	var root hocrSpan
+	if err := xml.Unmarshal(out, &root); err != nil {
+		t.Fatalf("hOCR is not well-formed: %v\n%s", err, out)
+	}
+	tests := []struct {
+		class  string
+		ids    []string
+		titles []string
+		texts  []string
+	}{
+		{class: "ocr_page", ids: []string{"page_1"}, titles: []string{"bbox 0 0 800 600; ppageno 0"}},
+		{class: "ocr_carea", ids: []string{"block_1_1", "block_1_2"}},
+		{class: "ocr_line", ids: []string{"line_1_1_1", "line_1_1_2", "line_1_2_1"}, titles: []string{"bbox 10 10 300 30", "bbox 10 40 120 60", "bbox 10 100 200 120"}},
+		{
+			class:  "ocrx_word",
+			ids:    []string{"word_1_1_1_1", "word_1_1_1_2", "word_1_1_2_1", "word_1_1_2_2", "word_1_2_1_1"},
+			titles: []string{"bbox 10 10 100 30; x_wconf 96", "bbox 110 10 300 30; x_wconf 89", "bbox 10 40 30 60; x_wconf 70", "bbox 40 40 120 60; x_wconf 55", "bbox 10 100 200 120; x_wconf 100"},
+			texts:  []string{"Hello", "<world>", "&", "co", "Bye"},
+		},
+	}
+	for _, tt := range tests {
+		found := root.collect(tt.class)
+		if len(found) != len(tt.ids) {
+			t.Errorf("%d %s elements, want %d", len(found), tt.class, len(tt.ids))
+			continue
+		}
+		for i, element := range found {
+			if element.ID != tt.ids[i] {
+				t.Errorf("%s %d id = %q, want %q", tt.class, i, element.ID, tt.ids[i])
+			}
+			if tt.titles != nil && element.Title != tt.titles[i] {
+				t.Errorf("%s %d title = %q, want %q", tt.class, i, element.Title, tt.titles[i])
+			}
+			if tt.texts != nil && element.Text != tt.texts[i] {
+				t.Errorf("%s %d text = %q, want %q", tt.class, i, element.Text, tt.texts[i])
+			}
+		}
+	}
+}
+
+func TestHOCRPageWithoutSize(t *testing.T) {
+	doc := testDocument()
+	doc.Pages[0].Width, doc.Pages[0].Height = 0, 0
+	out := string(HOCR(doc, ""))
+	if !strings.Contains(out, `title="bbox 10 10 300 120; ppageno 0"`) {
+		t.Errorf("page box is not the union of its blocks:\n%s", out)
+	}
+	if strings.Contains(out, "xml:lang") {
+		t.Error("hOCR without a language declares one")
+	}
+}
+
+func TestHOCREmptyDocument(t *testing.T) {
+	var root hocrSpan
+	if err := xml.Unmarshal(HOCR(&models.Document{}, ""), &root); err != nil {
+		t.Fatalf("hOCR of an empty document is not well-formed: %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package langdetect
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	// Unknown is returned when the text has too few letters to decide
+	Unknown = ""
+
+	maxNgram    = 3
+	minLetters  = 10
+	maxLetters  = 2000
+	smoothing   = 0.5
+	scriptShare = 0.5
+)
+
+// Result is the detected language (ISO 639-1 code) and a confidence in [0, 1]
+type Result struct {
+	Lang       string  `json:"lang"`
+	Confidence float64 `json:"confidence"`
+}
+
+type profile struct {
+	counts map[string]float64
+	total  float64
+}
+
+var profiles = buildProfiles()
+
+// scriptLanguages maps scripts used by a single supported language
+var scriptLanguages = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Thai, "th"},
+	{unicode.Greek, "el"},
+	{unicode.Hebrew, "he"},
+	{unicode.Arabic, "ar"},
+	{unicode.Devanagari, "hi"},
+}
+
+func buildProfiles() map[string]*profile {
+	built := make(map[string]*profile, len(samples))
+	for lang, text := range samples {
+		p := &profile{counts: map[string]float64{}}
+		for _, gram := range ngrams(normalize(text)) {
+			p.counts[gram]++
+			p.total++
+		}
+		built[lang] = p
+	}
+	return built
+}
+
+// Detect guesses the language of text. Scripts that belong to a single
+// language decide directly; Latin and Cyrillic text is scored against the
+// character n-gram profiles with a naive Bayes model.
+func Detect(text string) Result {
+	letters := 0
+	scripts := map[string]int{}
+	han, kana := 0, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if letters > maxLetters {
+			break
+		}
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			kana++
+		}
+		for _, s := range scriptLanguages {
+			if unicode.Is(s.table, r) {
+				scripts[s.lang]++
+				break
+			}
+		}
+	}
+
+	if letters == 0 {
+		return Result{Lang: Unknown}
+	}
+	if letters > maxLetters {
+		letters = maxLetters
+	}
+
+	// Japanese mixes kana with Han characters, Chinese uses Han alone
+	if han+kana > 0 && float64(han+kana)/float64(letters) >= scriptShare {
+		if kana > 0 {
+			return Result{Lang: "ja", Confidence: float64(han+kana) / float64(letters)}
+		}
+		return Result{Lang: "zh", Confidence: float64(han) / float64(letters)}
+	}
+	for lang, count := range scripts {
+		if float64(count)/float64(letters) >= scriptShare {
+			return Result{Lang: lang, Confidence: float64(count) / float64(letters)}
+		}
+	}
+
+	if letters < minLetters {
+		return Result{Lang: Unknown}
+	}
+	return detectByProfile(normalize(text))
+}
+
+func detectByProfile(normalized string) Result {
+	grams := ngrams(normalized)
+	if len(grams) == 0 {
+		return Result{Lang: Unknown}
+	}
+
+	scores := make(map[string]float64, len(profiles))
+	best, bestScore := Unknown, math.Inf(-1)
+	for lang, p := range profiles {
+		score := 0.0
+		vocabulary := float64(len(p.counts) + 1)
+		for _, gram := range grams {
+			score += math.Log((p.counts[gram] + smoothing) / (p.total + smoothing*vocabulary))
+		}
+		scores[lang] = score
+		if score > bestScore {
+			best, bestScore = lang, score
+		}
+	}
+
+	// softmax over the log likelihoods, scaled by the n-gram count so that the
+	// confidence does not saturate immediately on long texts
+	scale := 1.0 / math.Sqrt(float64(len(grams)))
+	sum := 0.0
+	for _, score := range scores {
+		sum += math.Exp((score - bestScore) * scale)
+	}
+
+	return Result{Lang: best, Confidence: 1 / sum}
+}
+
+// normalize lowercases text, keeps letters only and collapses everything
+// else into single spaces
+func normalize(text string) string {
+	var b strings.Builder
+	space := true
+	count := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			b.WriteRune(unicode.ToLower(r))
+			space = false
+			count++
+			if count >= maxLetters {
+				break
+			}
+			continue
+		}
+		if !space {
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// ngrams returns the 1 to maxNgram character grams of every word,
+// with the word padded by spaces so that prefixes and suffixes count
+func ngrams(normalized string) []string {
+	var grams []string
+	for _, word := range strings.Fields(normalized) {
+		runes := []rune(" " + word + " ")
+		for n := 1; n <= maxNgram; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				gram := string(runes[i : i+n])
+				if gram == " " {
+					continue
+				}
+				grams = append(grams, gram)
+			}
+		}
+	}
+	return grams
+}
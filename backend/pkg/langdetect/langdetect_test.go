@@ -0,0 +1,76 @@
+package langdetect
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"The invoice must be paid within thirty days of delivery, otherwise a late fee applies.", "en"},
+		{"Die Rechnung ist innerhalb von dreißig Tagen nach der Lieferung zu bezahlen.", "de"},
+		{"La facture doit être payée dans les trente jours suivant la livraison.", "fr"},
+		{"La factura debe pagarse dentro de los treinta días siguientes a la entrega.", "es"},
+		{"Hóa đơn phải được thanh toán trong vòng ba mươi ngày kể từ ngày giao hàng.", "vi"},
+		{"Счёт должен быть оплачен в течение тридцати дней после доставки.", "ru"},
+	}
+	// the translation trusts a confidence of 0.5 and above
+	for _, tt := range tests {
+		got := Detect(tt.text)
+		if got.Lang != tt.want || got.Confidence < 0.5 {
+			t.Errorf("Detect(%q) = %+v, want %s with a confidence of at least 0.5", tt.text, got, tt.want)
+		}
+	}
+
+	// a few words are a guess
+	if got := Detect("Invoice 請求書 paid"); got.Confidence >= 0.5 {
+		t.Errorf("Detect() of mixed text = %+v, want a confidence below 0.5", got)
+	}
+}
+
+func TestDetectByScript(t *testing.T) {
+	tests := []struct {
+		text string
+		want Result
+	}{
+		{"請求書は納品後三十日以内にお支払いください。", Result{Lang: "ja", Confidence: 1}},
+		{"发票必须在交货后三十天内付款。", Result{Lang: "zh", Confidence: 1}},
+		{"청구서는 배송 후 삼십일 이내에 지불해야 합니다.", Result{Lang: "ko", Confidence: 1}},
+		// 11 of the 18 letters are Greek
+		{"Το τιμολόγιο (invoice)", Result{Lang: "el", Confidence: 11.0 / 18}},
+		{"Hello", Result{Lang: Unknown}},
+		{"12/04/2024 - 1.250,00 €", Result{Lang: Unknown}},
+		{"", Result{Lang: Unknown}},
+	}
+	for _, tt := range tests {
+		if got := Detect(tt.text); got != tt.want {
+			t.Errorf("Detect(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"Hello, World!", "hello world"},
+		{"  3 apples -- 2 pears ", "apples pears"},
+		{"Größe", "größe"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalize(tt.text); got != tt.want {
+			t.Errorf("normalize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestNgrams(t *testing.T) {
+	got := strings.Join(ngrams("ab"), "|")
+	if want := "a|b| a|ab|b | ab|ab "; got != want {
+		t.Errorf("ngrams(%q) = %q, want %q", "ab", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package langdetect
+
+// samples holds a short training text per language. The n-gram profiles are
+// built from these at startup, so adding a language only needs a paragraph of
+// ordinary prose written in it.
+var samples = map[string]string{
+	"cs": `Všichni lidé rodí se svobodní a sobě rovní co do důstojnosti a práv. Jsou nadáni rozumem a svědomím a mají spolu jednat v duchu bratrství.
+Každý má právo na vzdělání. Vzdělání má směřovat k plnému rozvoji lidské osobnosti a k posílení úcty k lidským právům a základním svobodám.
+Dnes ráno jsme šli do města, protože jsme potřebovali koupit nové knihy a něco k jídlu. Počasí bylo pěkné, ale večer začalo pršet a museli jsme se vrátit domů.
+Tento návod popisuje, jak zařízení správně nainstalovat, jak jej používat a co dělat v případě, že se objeví chyba.`,
+
+	"da": `Alle mennesker er født frie og lige i værdighed og rettigheder. De er udstyret med fornuft og samvittighed, og de bør handle mod hverandre i en broderskabets ånd.
+Enhver har ret til undervisning. Undervisningen skal være gratis, i det mindste på de elementære og grundlæggende trin.
+I morges gik vi ned til byen, fordi vi skulle købe nogle nye bøger og lidt mad. Vejret var godt, men om aftenen begyndte det at regne, og vi måtte gå hjem igen.
+Denne vejledning beskriver, hvordan man installerer udstyret korrekt, hvordan man bruger det, og hvad man skal gøre, hvis der opstår en fejl.`,
+
+	"de": `Alle Menschen sind frei und gleich an Würde und Rechten geboren. Sie sind mit Vernunft und Gewissen begabt und sollen einander im Geist der Brüderlichkeit begegnen.
+Jeder hat das Recht auf Bildung. Die Bildung ist unentgeltlich, zum mindesten der Grundschulunterricht und die grundlegende Bildung.
+Heute Morgen sind wir in die Stadt gegangen, weil wir neue Bücher und etwas zu essen kaufen mussten. Das Wetter war schön, aber am Abend fing es an zu regnen und wir mussten nach Hause gehen.
+Diese Anleitung beschreibt, wie das Gerät richtig installiert wird, wie man es benutzt und was zu tun ist, wenn ein Fehler auftritt.`,
+
+	"en": `All human beings are born free and equal in dignity and rights. They are endowed with reason and conscience and should act towards one another in a spirit of brotherhood.
+Everyone has the right to education. Education shall be free, at least in the elementary and fundamental stages.
+This morning we went into town because we needed to buy some new books and something to eat. The weather was nice, but in the evening it started to rain and we had to go back home.
+This manual describes how to install the device correctly, how to use it and what to do when an error occurs.
+Please check the invoice number and the total amount due before you send the payment. The terms of delivery are listed on the back of this page, together with the contact details of our customer service team.`,
+
+	"es": `Todos los seres humanos nacen libres e iguales en dignidad y derechos y, dotados como están de razón y conciencia, deben comportarse fraternalmente los unos con los otros.
+Toda persona tiene derecho a la educación. La educación debe ser gratuita, al menos en lo concerniente a la instrucción elemental y fundamental.
+Esta mañana fuimos a la ciudad porque necesitábamos comprar unos libros nuevos y algo de comer. El tiempo era bueno, pero por la tarde empezó a llover y tuvimos que volver a casa.
+Este manual describe cómo instalar el dispositivo correctamente, cómo utilizarlo y qué hacer cuando se produce un error.`,
+
+	"fi": `Kaikki ihmiset syntyvät vapaina ja tasavertaisina arvoltaan ja oikeuksiltaan. Heille on annettu järki ja omatunto, ja heidän on toimittava toisiaan kohtaan veljeyden hengessä.
+Jokaisella on oikeus saada opetusta. Opetuksen tulee olla maksutonta ainakin alkeis- ja perusopetuksen osalta.
+Tänä aamuna menimme kaupunkiin, koska meidän piti ostaa uusia kirjoja ja jotain syötävää. Sää oli kaunis, mutta illalla alkoi sataa ja meidän täytyi palata kotiin.
+Tämä käyttöohje kertoo, miten laite asennetaan oikein, miten sitä käytetään ja mitä tehdä, jos tapahtuu virhe.`,
+
+	"fr": `Tous les êtres humains naissent libres et égaux en dignité et en droits. Ils sont doués de raison et de conscience et doivent agir les uns envers les autres dans un esprit de fraternité.
+Toute personne a droit à l'éducation. L'éducation doit être gratuite, au moins en ce qui concerne l'enseignement élémentaire et fondamental.
+Ce matin nous sommes allés en ville parce que nous devions acheter de nouveaux livres et quelque chose à manger. Il faisait beau, mais le soir il a commencé à pleuvoir et nous avons dû rentrer à la maison.
+Ce manuel décrit comment installer correctement l'appareil, comment l'utiliser et que faire lorsqu'une erreur se produit.`,
+
+	"hu": `Minden emberi lény szabadnak születik és egyenlő méltósága és joga van. Az emberek, ésszel és lelkiismerettel bírván, egymással szemben testvéri szellemben kell hogy viseltessenek.
+Minden személynek joga van a neveléshez. A nevelésnek, legalábbis az elemi és alapvető oktatást illetően, ingyenesnek kell lennie.
+Ma reggel bementünk a városba, mert új könyveket és valami ennivalót kellett vennünk. Az idő szép volt, de este esni kezdett az eső, és haza kellett mennünk.
+Ez a kézikönyv leírja, hogyan kell a készüléket helyesen telepíteni, hogyan kell használni, és mit kell tenni, ha hiba lép fel.`,
+
+	"id": `Semua orang dilahirkan merdeka dan mempunyai martabat dan hak-hak yang sama. Mereka dikaruniai akal dan hati nurani dan hendaknya bergaul satu sama lain dalam semangat persaudaraan.
+Setiap orang berhak mendapat pendidikan. Pendidikan harus gratis, setidak-tidaknya untuk tingkat sekolah rendah dan pendidikan dasar.
+Pagi ini kami pergi ke kota karena kami perlu membeli beberapa buku baru dan sesuatu untuk dimakan. Cuacanya bagus, tetapi pada sore hari mulai hujan dan kami harus pulang ke rumah.
+Panduan ini menjelaskan cara memasang perangkat dengan benar, cara menggunakannya dan apa yang harus dilakukan jika terjadi kesalahan.`,
+
+	"it": `Tutti gli esseri umani nascono liberi ed eguali in dignità e diritti. Essi sono dotati di ragione e di coscienza e devono agire gli uni verso gli altri in spirito di fratellanza.
+Ogni individuo ha diritto all'istruzione. L'istruzione deve essere gratuita almeno per quanto riguarda le classi elementari e fondamentali.
+Stamattina siamo andati in città perché dovevamo comprare dei libri nuovi e qualcosa da mangiare. Il tempo era bello, ma la sera ha cominciato a piovere e siamo dovuti tornare a casa.
+Questo manuale descrive come installare correttamente il dispositivo, come utilizzarlo e cosa fare quando si verifica un errore.`,
+
+	"nl": `Alle mensen worden vrij en gelijk in waardigheid en rechten geboren. Zij zijn begiftigd met verstand en geweten, en behoren zich jegens elkander in een geest van broederschap te gedragen.
+Een ieder heeft recht op onderwijs. Het onderwijs zal kosteloos zijn, althans wat het lager en basisonderwijs betreft.
+Vanochtend zijn we naar de stad gegaan omdat we een paar nieuwe boeken en iets te eten moesten kopen. Het weer was mooi, maar 's avonds begon het te regenen en moesten we weer naar huis.
+Deze handleiding beschrijft hoe het apparaat correct wordt geïnstalleerd, hoe het gebruikt wordt en wat te doen wanneer er een fout optreedt.
+Controleer het factuurnummer en het totale bedrag voordat u de betaling verstuurt. De leveringsvoorwaarden staan op de achterkant van deze pagina, samen met de gegevens van onze klantenservice.`,
+
+	"pl": `Wszyscy ludzie rodzą się wolni i równi pod względem swej godności i swych praw. Są oni obdarzeni rozumem i sumieniem i powinni postępować wobec innych w duchu braterstwa.
+Każdy człowiek ma prawo do nauki. Nauka powinna być bezpłatna, przynajmniej na stopniu podstawowym i elementarnym.
+Dziś rano poszliśmy do miasta, ponieważ musieliśmy kupić kilka nowych książek i coś do jedzenia. Pogoda była ładna, ale wieczorem zaczęło padać i musieliśmy wrócić do domu.
+Ta instrukcja opisuje, jak prawidłowo zainstalować urządzenie, jak z niego korzystać i co zrobić, gdy wystąpi błąd.`,
+
+	"pt": `Todos os seres humanos nascem livres e iguais em dignidade e em direitos. Dotados de razão e de consciência, devem agir uns para com os outros em espírito de fraternidade.
+Toda a pessoa tem direito à educação. A educação deve ser gratuita, pelo menos a correspondente ao ensino elementar fundamental.
+Hoje de manhã fomos à cidade porque precisávamos de comprar alguns livros novos e qualquer coisa para comer. O tempo estava bom, mas à noite começou a chover e tivemos de voltar para casa.
+Este manual descreve como instalar corretamente o dispositivo, como utilizá-lo e o que fazer quando ocorre um erro.`,
+
+	"ro": `Toate ființele umane se nasc libere și egale în demnitate și în drepturi. Ele sunt înzestrate cu rațiune și conștiință și trebuie să se comporte unele față de altele în spiritul fraternității.
+Orice persoană are dreptul la învățătură. Învățământul trebuie să fie gratuit, cel puțin în ceea ce privește învățământul elementar și general.
+În această dimineață am mers în oraș pentru că trebuia să cumpărăm câteva cărți noi și ceva de mâncare. Vremea a fost frumoasă, dar seara a început să plouă și a trebuit să ne întoarcem acasă.
+Acest manual descrie cum se instalează corect dispozitivul, cum se utilizează și ce trebuie făcut atunci când apare o eroare.`,
+
+	"ru": `Все люди рождаются свободными и равными в своем достоинстве и правах. Они наделены разумом и совестью и должны поступать в отношении друг друга в духе братства.
+Каждый человек имеет право на образование. Образование должно быть бесплатным по меньшей мере в том, что касается начального и общего образования.
+Сегодня утром мы пошли в город, потому что нам нужно было купить несколько новых книг и что-нибудь поесть. Погода была хорошая, но вечером начался дождь, и нам пришлось вернуться домой.
+Это руководство описывает, как правильно установить устройство, как его использовать и что делать, если возникает ошибка.`,
+
+	"sv": `Alla människor är födda fria och lika i värde och rättigheter. De har utrustats med förnuft och samvete och bör handla gentemot varandra i en anda av broderskap.
+Var och en har rätt till undervisning. Undervisningen skall vara kostnadsfri, åtminstone på de elementära och grundläggande stadierna.
+I morse gick vi in till staden eftersom vi behövde köpa några nya böcker och något att äta. Vädret var fint, men på kvällen började det regna och vi var tvungna att gå hem igen.
+Den här handboken beskriver hur man installerar enheten på rätt sätt, hur man använder den och vad man ska göra när ett fel uppstår.`,
+
+	"tr": `Bütün insanlar hür, haysiyet ve haklar bakımından eşit doğarlar. Akıl ve vicdana sahiptirler ve birbirlerine karşı kardeşlik zihniyeti ile hareket etmelidirler.
+Her şahsın öğrenim hakkı vardır. Öğrenim hiç değilse ilk ve temel safhalarında parasızdır.
+Bu sabah şehre gittik çünkü birkaç yeni kitap ve yiyecek bir şeyler almamız gerekiyordu. Hava güzeldi, ama akşam yağmur yağmaya başladı ve eve dönmek zorunda kaldık.
+Bu kılavuz, cihazın nasıl doğru şekilde kurulacağını, nasıl kullanılacağını ve bir hata oluştuğunda ne yapılması gerektiğini açıklamaktadır.`,
+
+	"uk": `Всі люди народжуються вільними і рівними у своїй гідності та правах. Вони наділені розумом і совістю і повинні діяти у відношенні один до одного в дусі братерства.
+Кожна людина має право на освіту. Освіта повинна бути безплатною, хоча б початкова і загальна.
+Сьогодні вранці ми пішли до міста, тому що нам треба було купити кілька нових книжок і щось поїсти. Погода була гарна, але ввечері почався дощ, і нам довелося повернутися додому.
+Цей посібник описує, як правильно встановити пристрій, як ним користуватися і що робити, якщо виникає помилка.`,
+
+	"vi": `Tất cả mọi người sinh ra đều được tự do và bình đẳng về nhân phẩm và quyền lợi. Mọi con người đều được tạo hóa ban cho lý trí và lương tâm và cần phải đối xử với nhau trong tình anh em.
+Mọi người đều có quyền được học hành. Giáo dục phải được miễn phí, ít nhất là ở bậc tiểu học và giáo dục cơ sở.
+Sáng nay chúng tôi đã đi vào thành phố vì chúng tôi cần mua vài quyển sách mới và một ít đồ ăn. Thời tiết rất đẹp, nhưng đến buổi tối thì trời bắt đầu mưa và chúng tôi phải trở về nhà.
+Tài liệu hướng dẫn này mô tả cách cài đặt thiết bị đúng cách, cách sử dụng thiết bị và những việc cần làm khi xảy ra lỗi.`,
+}
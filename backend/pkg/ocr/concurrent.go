@@ -0,0 +1,132 @@
+package ocr
+
+import (
+	"backend/models"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+)
+
+// workersFromEnv reads the number of images of one job recognized at the
+// same time from key, by default the number of CPUs
+func workersFromEnv(key string) int {
+	workers, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || workers < 1 {
+		return runtime.NumCPU()
+	}
+	return workers
+}
+
+// OCRFilterConcurrent performs OCR on the segments of an image concurrently
+// and returns their text in page order
+func OCRFilterConcurrent(ctx context.Context, engine OCREngine, imagePaths []string, cfg models.OCRConfig) (string, error) {
+	doc, err := OCRDocumentConcurrent(ctx, engine, imagePaths, cfg)
+	return doc.Text(), err
+}
+
+// OCRDocumentConcurrent recognizes the segments of an image, horizontal
+// strips from top to bottom as segmentation.SplitImage cuts them, with
+// OCR_SEGMENT_WORKERS workers of engine. The segments are merged into a single page in
+// their order.
+//
+// Every failed segment is reported in the joined error, the document then
+// holds the other segments. Cancelling ctx stops the remaining segments.
+func OCRDocumentConcurrent(ctx context.Context, engine OCREngine, imagePaths []string, cfg models.OCRConfig) (*models.Document, error) {
+	segments, errs := recognizeConcurrent(ctx, len(imagePaths), workersFromEnv("OCR_SEGMENT_WORKERS"),
+		func(i int) (*models.Document, error) {
+			return engine.Recognize(imagePaths[i], cfg)
+		})
+	return mergeSegments(segments), joinErrors("segment", imagePaths, errs)
+}
+
+// recognizeConcurrent calls recognize for the images 0 to count-1 with a
+// bounded number of workers and returns the results and errors by image
+func recognizeConcurrent(ctx context.Context, count, workers int, recognize func(i int) (*models.Document, error)) ([]*models.Document, []error) {
+	docs := make([]*models.Document, count)
+	errs := make([]error, count)
+
+	indexes := make(chan int)
+	done := make(chan struct{})
+	workers = min(workers, count)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+
+			// each worker writes only the slots of the indexes it receives
+			for i := range indexes {
+				if ctx.Err() != nil {
+					errs[i] = ctx.Err()
+					continue
+				}
+				docs[i], errs[i] = recognize(i)
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+		}
+	}
+	close(indexes)
+	for w := 0; w < workers; w++ {
+		<-done
+	}
+	return docs, errs
+}
+
+// joinErrors reports every failed image, nil if none failed
+func joinErrors(kind string, imagePaths []string, errs []error) error {
+	var failures []error
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, fmt.Errorf("%s %d (%s): %w", kind, i, imagePaths[i], err))
+		}
+	}
+	return errors.Join(failures...)
+}
+
+// mergeSegments stacks the segment pages into one, moving the boxes of each
+// segment below the previous ones. Failed segments are skipped.
+func mergeSegments(segments []*models.Document) *models.Document {
+	page := models.Page{Number: 1}
+	offset := 0
+	for _, segment := range segments {
+		if segment == nil {
+			continue
+		}
+		for _, segmentPage := range segment.Pages {
+			for _, block := range segmentPage.Blocks {
+				block.BBox = shift(block.BBox, offset)
+				lines := make([]models.Line, len(block.Lines))
+				for l, line := range block.Lines {
+					line.BBox = shift(line.BBox, offset)
+					words := make([]models.Word, len(line.Words))
+					for w, word := range line.Words {
+						word.BBox = shift(word.BBox, offset)
+						words[w] = word
+					}
+					line.Words = words
+					lines[l] = line
+				}
+				block.Lines = lines
+				page.Blocks = append(page.Blocks, block)
+			}
+			page.Width = max(page.Width, segmentPage.Width)
+			offset += segmentPage.Height
+		}
+	}
+	page.Height = offset
+	return &models.Document{Pages: []models.Page{page}}
+}
+
+func shift(box models.BBox, dy int) models.BBox {
+	box.Y0 += dy
+	box.Y1 += dy
+	return box
+}
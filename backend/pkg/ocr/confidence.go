@@ -0,0 +1,54 @@
+package ocr
+
+import (
+	"backend/models"
+	"os"
+	"strconv"
+)
+
+const (
+	// DefaultJobConfidenceThreshold flags jobs whose average word confidence is lower
+	DefaultJobConfidenceThreshold = 70
+	// DefaultWordConfidenceThreshold marks words with a lower confidence in the PDF
+	DefaultWordConfidenceThreshold = 60
+)
+
+// JobConfidenceThreshold reads OCR_JOB_CONFIDENCE_THRESHOLD
+func JobConfidenceThreshold() float64 {
+	return envConfidence("OCR_JOB_CONFIDENCE_THRESHOLD", DefaultJobConfidenceThreshold)
+}
+
+// WordConfidenceThreshold reads OCR_WORD_CONFIDENCE_THRESHOLD
+func WordConfidenceThreshold() float64 {
+	return envConfidence("OCR_WORD_CONFIDENCE_THRESHOLD", DefaultWordConfidenceThreshold)
+}
+
+func envConfidence(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil || value < 0 || value > 100 {
+		return fallback
+	}
+	return value
+}
+
+// ScoreJob sets the confidence fields of the job from its document. A page
+// without words gets -1 and does not count towards the job average.
+func ScoreJob(job *models.Job) {
+	if job.Document == nil {
+		return
+	}
+
+	job.PageConfidences = make([]float64, len(job.Document.Pages))
+	for p := range job.Document.Pages {
+		mean, ok := job.Document.Pages[p].MeanConfidence()
+		if !ok {
+			mean = -1
+		}
+		job.PageConfidences[p] = mean
+	}
+
+	mean, ok := job.Document.MeanConfidence()
+	job.OCRConfidence = mean
+	// a page without any recognized word is as unreliable as it gets
+	job.LowConfidence = !ok || mean < JobConfidenceThreshold()
+}
@@ -0,0 +1,14 @@
+package ocr
+
+import (
+	"backend/models"
+)
+
+// OCREngine recognizes page images. The workers and the sync server depend
+// on an engine rather than on Tesseract, so that the pipeline also runs with
+// FakeEngine; package tesseract has the Tesseract one.
+type OCREngine interface {
+	// Recognize returns the structured result of the image at imagePath, a
+	// document of one page
+	Recognize(imagePath string, cfg models.OCRConfig) (*models.Document, error)
+}
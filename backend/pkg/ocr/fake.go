@@ -0,0 +1,111 @@
+package ocr
+
+import (
+	"backend/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownImage is returned by FakeEngine for images it has no result for
+var ErrUnknownImage = errors.New("no canned OCR result for image")
+
+// Sizes of the boxes FakeEngine lays the words of a canned text out with, in
+// pixels
+const (
+	fakeCharWidth  = 10
+	fakeLineHeight = 20
+	fakeConfidence = 95
+)
+
+// FakeEngine is a deterministic OCREngine for tests: it returns canned
+// results by the SHA-256 of the image file, see ImageHash. It only knows the
+// files it is given, preprocessed images and segments are files of their
+// own, with hashes of their own.
+type FakeEngine struct {
+	mu   sync.Mutex
+	docs map[string]*models.Document
+}
+
+func NewFakeEngine() *FakeEngine {
+	return &FakeEngine{docs: map[string]*models.Document{}}
+}
+
+// ImageHash returns the hex SHA-256 of the image file FakeEngine keys its
+// results by
+func ImageHash(imagePath string) (string, error) {
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Add sets the document, with its boxes, returned for the image with hash
+func (e *FakeEngine) Add(hash string, doc *models.Document) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.docs[hash] = doc.Clone()
+}
+
+// AddText sets the text returned for the image with hash. Paragraphs are
+// separated by blank lines; the words are given boxes in a fixed grid and
+// a confidence of 95.
+func (e *FakeEngine) AddText(hash, text string) {
+	e.Add(hash, fakeDocument(text))
+}
+
+func (e *FakeEngine) Recognize(imagePath string, cfg models.OCRConfig) (*models.Document, error) {
+	hash, err := ImageHash(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	doc, ok := e.docs[hash]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownImage, imagePath)
+	}
+	return doc.Clone(), nil
+}
+
+// fakeDocument lays text out one word after the other, a line of text per
+// line of the page and a blank line between paragraphs
+func fakeDocument(text string) *models.Document {
+	page := models.Page{Number: 1}
+	y := 0
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		var block models.Block
+		for _, row := range strings.Split(paragraph, "\n") {
+			var line models.Line
+			x := 0
+			for _, token := range strings.Fields(row) {
+				width := fakeCharWidth * len([]rune(token))
+				bbox := models.BBox{X0: x, Y0: y, X1: x + width, Y1: y + fakeLineHeight}
+				line.Words = append(line.Words, models.Word{BBox: bbox, Text: token, Confidence: fakeConfidence})
+				line.BBox = line.BBox.Union(bbox)
+				x += width + fakeCharWidth
+			}
+			if len(line.Words) == 0 {
+				continue
+			}
+			block.Lines = append(block.Lines, line)
+			block.BBox = block.BBox.Union(line.BBox)
+			page.Width = max(page.Width, line.BBox.X1)
+			y += fakeLineHeight
+		}
+		if len(block.Lines) == 0 {
+			continue
+		}
+		block.Text = ParagraphText(block.Lines)
+		page.Blocks = append(page.Blocks, block)
+		y += fakeLineHeight
+	}
+	page.Height = y
+	return &models.Document{Pages: []models.Page{page}}
+}
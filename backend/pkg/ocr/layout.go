@@ -0,0 +1,95 @@
+package ocr
+
+import (
+	"backend/models"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// listItem matches the start of a bulleted or numbered line: "•", "-", "1.", "a)", "(iv)"
+var listItem = regexp.MustCompile(`^(?:[•◦▪·*\-–—]|\(?(?:\d{1,3}|[a-zA-Z]|[ivxIVX]{1,4})[.)])$`)
+
+// columnGap is how many line heights of blank space between two words make
+// them separate columns (a table row or a form) rather than one sentence
+const columnGap = 2.5
+
+// ParagraphText joins the lines of a paragraph into running text, removing
+// the hyphen of words broken across lines. List items and lines with column
+// gaps keep their own line.
+func ParagraphText(lines []models.Line) string {
+	var out strings.Builder
+	previousKept := false
+	for i, line := range lines {
+		text, tabular := lineText(line)
+		if text == "" {
+			continue
+		}
+		keep := tabular || isListItem(line)
+
+		if i > 0 && out.Len() > 0 {
+			trimmed, broken := endsWithBrokenWord(out.String())
+			switch {
+			case keep || previousKept:
+				out.WriteString("\n")
+			case broken && startsLower(text):
+				// "inter-" + "national" -> "international"
+				out.Reset()
+				out.WriteString(trimmed)
+			case broken:
+				// a hyphenated compound such as "Jean-" + "Paul"
+			default:
+				out.WriteString(" ")
+			}
+		}
+		out.WriteString(text)
+		previousKept = keep
+	}
+	return out.String()
+}
+
+// lineText joins the words of the line, a gap wider than columnGap line
+// heights is kept as a tab
+func lineText(line models.Line) (string, bool) {
+	var out strings.Builder
+	tabular := false
+	for i, word := range line.Words {
+		if i > 0 {
+			previous := line.Words[i-1].BBox
+			height := max(previous.Height(), word.BBox.Height())
+			if height > 0 && float64(word.BBox.X0-previous.X1) > columnGap*float64(height) {
+				out.WriteString("\t")
+				tabular = true
+			} else {
+				out.WriteString(" ")
+			}
+		}
+		out.WriteString(word.Text)
+	}
+	return out.String(), tabular
+}
+
+func isListItem(line models.Line) bool {
+	return len(line.Words) > 1 && listItem.MatchString(line.Words[0].Text)
+}
+
+// Hyphens can end a line in the middle of a word, U+00AD is the soft hyphen
+const Hyphens = "-\u00ad"
+
+// endsWithBrokenWord reports whether text ends in a letter followed by a
+// hyphen, and returns text without it
+func endsWithBrokenWord(text string) (string, bool) {
+	r, size := utf8.DecodeLastRuneInString(text)
+	if !strings.ContainsRune(Hyphens, r) {
+		return text, false
+	}
+	trimmed := text[:len(text)-size]
+	before, _ := utf8.DecodeLastRuneInString(trimmed)
+	return trimmed, unicode.IsLetter(before)
+}
+
+func startsLower(text string) bool {
+	r, _ := utf8.DecodeRuneInString(text)
+	return unicode.IsLower(r)
+}
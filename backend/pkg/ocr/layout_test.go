@@ -0,0 +1,75 @@
+package ocr
+
+import (
+	"backend/models"
+	"strings"
+	"testing"
+)
+
+// lines lays out rows of words, "|" marks a column gap
+func lines(rows ...string) []models.Line {
+	var out []models.Line
+	for y, row := range rows {
+		var line models.Line
+		x := 0
+		for _, token := range strings.Fields(row) {
+			if token == "|" {
+				x += 100
+				continue
+			}
+			box := models.BBox{X0: x, Y0: 20 * y, X1: x + 10*len([]rune(token)), Y1: 20*y + 10}
+			line.Words = append(line.Words, models.Word{BBox: box, Text: token})
+			x = box.X1 + 5
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+func TestParagraphText(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []models.Line
+		want  string
+	}{
+		{"running text", lines("The quick brown", "fox jumps."), "The quick brown fox jumps."},
+		{"broken word", lines("an inter-", "national deal"), "an international deal"},
+		{"soft hyphen", lines("an inter\u00ad", "national deal"), "an international deal"},
+		{"compound", lines("met Jean-", "Paul there"), "met Jean-Paul there"},
+		{"dash", lines("wait -", "then go"), "wait - then go"},
+		{"list items", lines("Needs:", "• milk", "1. eggs", "a) bread"), "Needs:\n• milk\n1. eggs\na) bread"},
+		{"columns", lines("Name | Jane", "Total | 42"), "Name\tJane\nTotal\t42"},
+		{"empty lines", lines("", "alone", ""), "alone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParagraphText(tt.lines); got != tt.want {
+				t.Errorf("ParagraphText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFakeEngine(t *testing.T) {
+	engine := NewFakeEngine()
+	img := blankImage(20, 20)
+	path := saveImage(t, img)
+	engine.AddText(grayHash(t, img), "Hello world\nagain\n\nBye")
+
+	doc, err := engine.Recognize(path, models.OCRConfig{})
+	if err != nil {
+		t.Fatalf("Recognize() error = %v", err)
+	}
+	if got, want := doc.Text(), "Hello world again\n\nBye"; got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+	// results are copies, a job changing its document leaves the next alone
+	doc.Pages[0].Blocks[0].Text = "changed"
+	if again, _ := engine.Recognize(path, models.OCRConfig{}); again.Pages[0].Blocks[0].Text != "Hello world again" {
+		t.Error("Recognize() returned a shared document")
+	}
+
+	if _, err := engine.Recognize(saveImage(t, blankImage(21, 20)), models.OCRConfig{}); err == nil {
+		t.Error("Recognize() of an unknown image succeeded")
+	}
+}
@@ -0,0 +1,165 @@
+package ocr
+
+import (
+	"backend/models"
+	"backend/pkg/preprocess"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"slices"
+)
+
+// DetectOrientation returns by how many degrees, clockwise, img must be
+// turned for its text to be upright. gosseract has no binding for
+// Tesseract's orientation detection, so a projection profile tells
+// horizontal from vertical lines, then engine recognizes the page both ways
+// round and the more confident reading wins.
+func DetectOrientation(engine OCREngine, img *image.Gray, cfg models.OCRConfig) (int, error) {
+	candidates := []int{0, 180}
+	if preprocess.TextVertical(img) {
+		candidates = []int{90, 270}
+	}
+
+	best, bestScore := candidates[0], -1.0
+	for _, degrees := range candidates {
+		score, err := orientationScore(engine, preprocess.Rotate(img, degrees), cfg)
+		if err != nil {
+			return 0, err
+		}
+		if score > bestScore {
+			best, bestScore = degrees, score
+		}
+	}
+	return best, nil
+}
+
+// orientationScore sums the confidence of the words recognized in img, text
+// read the wrong way round gives few words, all of them doubtful
+func orientationScore(engine OCREngine, img *image.Gray, cfg models.OCRConfig) (float64, error) {
+	path, err := preprocess.SaveTemp(img)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(path)
+
+	doc, err := engine.Recognize(path, cfg)
+	if err != nil {
+		return 0, fmt.Errorf("failed to recognize orientation: %w", err)
+	}
+	var score float64
+	for _, block := range doc.Blocks() {
+		for _, line := range block.Lines {
+			for _, word := range line.Words {
+				if word.Confidence > 0 && len([]rune(word.Text)) > 1 {
+					score += word.Confidence
+				}
+			}
+		}
+	}
+	return score, nil
+}
+
+// PreparedImage is the image given to Tesseract for a job
+type PreparedImage struct {
+	// Path is the original image or, once turned or preprocessed, a
+	// temporary file
+	Path string
+	// Orientation is by how many degrees, clockwise, the page was turned
+	// after its EXIF orientation. OrientationDetected is false when
+	// preprocess.StepOrient did not run or failed, Orientation is then 0
+	// without telling whether the page was upright.
+	Orientation         int
+	OrientationDetected bool
+	temporary           bool
+
+	// geometry maps the prepared image back to the upright original, of
+	// width x height pixels
+	geometry      preprocess.Geometry
+	width, height int
+}
+
+// Cleanup removes the temporary file of the image
+func (p *PreparedImage) Cleanup() {
+	if p.temporary {
+		os.Remove(p.Path)
+	}
+}
+
+// Unmap moves the boxes of doc, recognized from the prepared image, to the
+// upright original image, which the searchable PDF shows under them.
+// Upscaling and deskewing move the text of the prepared image.
+func (p *PreparedImage) Unmap(doc *models.Document) {
+	if !p.geometry.Moved() {
+		return
+	}
+	for i := range doc.Pages {
+		page := &doc.Pages[i]
+		page.Width, page.Height = p.width, p.height
+		unmapPage(page, func(box models.BBox) models.BBox {
+			return toBBox(p.geometry.UnmapRect(toRect(box)))
+		})
+	}
+}
+
+// unmapPage replaces every box of page by place of it
+func unmapPage(page *models.Page, place func(models.BBox) models.BBox) {
+	for b := range page.Blocks {
+		block := &page.Blocks[b]
+		block.BBox = place(block.BBox)
+		for l := range block.Lines {
+			line := &block.Lines[l]
+			line.BBox = place(line.BBox)
+			for w := range line.Words {
+				line.Words[w].BBox = place(line.Words[w].BBox)
+			}
+		}
+		if block.Table != nil {
+			for _, row := range block.Table.Rows {
+				for c := range row {
+					row[c].BBox = place(row[c].BBox)
+				}
+			}
+		}
+	}
+}
+
+// PrepareImage honors the EXIF orientation of imagePath and runs the
+// preprocessing steps on it, StepOrient first. name prefixes the debug
+// images of preprocess.DebugSaver. engine detects the orientation.
+func PrepareImage(engine OCREngine, imagePath string, steps []string, cfg models.OCRConfig, name string) (*PreparedImage, error) {
+	if len(steps) == 0 && !preprocess.Oriented(imagePath) {
+		return &PreparedImage{Path: imagePath}, nil
+	}
+
+	img, err := preprocess.Load(imagePath)
+	if errors.Is(err, image.ErrFormat) {
+		// a TIFF page only Tesseract can read
+		log.Printf("Can not preprocess %s, recognizing it as is: %v", imagePath, err)
+		return &PreparedImage{Path: imagePath}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prepared := &PreparedImage{temporary: true}
+	if slices.Contains(steps, preprocess.StepOrient) {
+		orientation, err := DetectOrientation(engine, img, cfg)
+		if err != nil {
+			// a page that can not be turned is still worth reading
+			log.Printf("Failed to detect the orientation of %s: %v", imagePath, err)
+		} else {
+			prepared.Orientation, prepared.OrientationDetected = orientation, true
+			img = preprocess.Rotate(img, orientation)
+		}
+	}
+
+	prepared.width, prepared.height = img.Rect.Dx(), img.Rect.Dy()
+	img, prepared.geometry = preprocess.ApplyGeometry(img, steps, preprocess.DebugSaver(name))
+	prepared.Path, err = preprocess.SaveTemp(img)
+	if err != nil {
+		return nil, err
+	}
+	return prepared, nil
+}
@@ -0,0 +1,196 @@
+package ocr
+
+import (
+	"backend/models"
+	"backend/pkg/preprocess"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// linesPage is a page of four lines of text, wider than high
+func linesPage() *image.Gray {
+	page := blankImage(200, 120)
+	for y := 20; y < 100; y += 20 {
+		fillRect(page, image.Rect(20, y, 180, y+8))
+	}
+	return page
+}
+
+// orientationEngine reads page the right way up with a higher confidence
+// than upside down, as Tesseract does
+func orientationEngine(t *testing.T, page *image.Gray) *FakeEngine {
+	t.Helper()
+	engine := NewFakeEngine()
+	engine.Add(grayHash(t, page), wordDoc("text", models.BBox{X1: 10, Y1: 10}, 90))
+	engine.Add(grayHash(t, preprocess.Rotate(page, 180)), wordDoc("ʇxǝʇ", models.BBox{X1: 10, Y1: 10}, 20))
+	return engine
+}
+
+func TestDetectOrientation(t *testing.T) {
+	page := linesPage()
+	engine := orientationEngine(t, page)
+
+	tests := []struct {
+		name string
+		img  *image.Gray
+		want int
+	}{
+		{"upright", page, 0},
+		{"upside down", preprocess.Rotate(page, 180), 180},
+		// the lines run from top to bottom, only quarter turns are tried
+		{"turned clockwise", preprocess.Rotate(page, 90), 270},
+		{"turned counterclockwise", preprocess.Rotate(page, 270), 90},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectOrientation(engine, tt.img, models.OCRConfig{})
+			if err != nil {
+				t.Fatalf("DetectOrientation() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DetectOrientation() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := DetectOrientation(NewFakeEngine(), page, models.OCRConfig{}); !errors.Is(err, ErrUnknownImage) {
+		t.Errorf("DetectOrientation() with no result error = %v, want %v", err, ErrUnknownImage)
+	}
+}
+
+// saveJPEG writes img as a JPEG file whose EXIF orientation is orientation
+func saveJPEG(t *testing.T, img *image.Gray, orientation uint16) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a big endian TIFF header with a single IFD entry, the orientation
+	exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	exif = binary.BigEndian.AppendUint16(exif, 0x0112)
+	exif = binary.BigEndian.AppendUint16(exif, 3)
+	exif = binary.BigEndian.AppendUint32(exif, 1)
+	exif = binary.BigEndian.AppendUint16(exif, orientation)
+	exif = append(exif, 0, 0, 0, 0, 0, 0)
+	segment := binary.BigEndian.AppendUint16([]byte{0xFF, 0xE1}, uint16(len(exif)+2))
+	segment = append(segment, exif...)
+
+	data := buf.Bytes()
+	data = append(append(data[:2:2], segment...), data[2:]...)
+	path := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPrepareImageEXIF(t *testing.T) {
+	// a photo stored a quarter turn counterclockwise, EXIF orientation 6
+	// turns it back
+	path := saveJPEG(t, preprocess.Rotate(linesPage(), 270), 6)
+	upright, err := preprocess.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upright.Rect != image.Rect(0, 0, 200, 120) {
+		t.Fatalf("Load() bounds = %v, want the upright %v", upright.Rect, image.Rect(0, 0, 200, 120))
+	}
+	engine := orientationEngine(t, upright)
+
+	tests := []struct {
+		name         string
+		steps        []string
+		wantDetected bool
+	}{
+		{name: "no steps", steps: nil},
+		{name: "orientation detected", steps: []string{preprocess.StepOrient}, wantDetected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prepared, err := PrepareImage(engine, path, tt.steps, models.OCRConfig{}, "job")
+			if err != nil {
+				t.Fatalf("PrepareImage() error = %v", err)
+			}
+			defer prepared.Cleanup()
+			if prepared.Path == path {
+				t.Fatal("PrepareImage() gave Tesseract the photo, which it reads without its EXIF orientation")
+			}
+			if prepared.Orientation != 0 || prepared.OrientationDetected != tt.wantDetected {
+				t.Errorf("Orientation = %d, detected %v, want 0, detected %v", prepared.Orientation, prepared.OrientationDetected, tt.wantDetected)
+			}
+
+			// the engine is given the upright page
+			doc, err := engine.Recognize(prepared.Path, models.OCRConfig{})
+			if err != nil {
+				t.Fatalf("Recognize() error = %v", err)
+			}
+			if got := doc.Text(); got != "text" {
+				t.Errorf("Recognize() of the prepared image = %q, want %q", got, "text")
+			}
+		})
+	}
+}
+
+func TestPrepareImageOrientationFailed(t *testing.T) {
+	page := preprocess.Rotate(linesPage(), 180)
+	path := saveImage(t, page)
+
+	// an engine with no result for the page can not tell which way is up
+	prepared, err := PrepareImage(NewFakeEngine(), path, []string{preprocess.StepOrient}, models.OCRConfig{}, "job")
+	if err != nil {
+		t.Fatalf("PrepareImage() error = %v", err)
+	}
+	defer prepared.Cleanup()
+	if prepared.Orientation != 0 || prepared.OrientationDetected {
+		t.Errorf("Orientation = %d, detected %v, want 0, not detected", prepared.Orientation, prepared.OrientationDetected)
+	}
+	hash, err := ImageHash(prepared.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != grayHash(t, page) {
+		t.Error("PrepareImage() turned the page without knowing its orientation")
+	}
+}
+
+func TestPreparedImageUnmap(t *testing.T) {
+	page := linesPage()
+	path := saveImage(t, page)
+	steps := []string{preprocess.StepUpscale}
+
+	// Tesseract reads the page upscaled by 4, the first line at 4 times its
+	// place on the page
+	upscaled := preprocess.Apply(page, steps, nil)
+	doc := wordDoc("text", models.BBox{X0: 80, Y0: 80, X1: 720, Y1: 112}, 90)
+	doc.Pages[0].Width, doc.Pages[0].Height = upscaled.Rect.Dx(), upscaled.Rect.Dy()
+	engine := NewFakeEngine()
+	engine.Add(grayHash(t, upscaled), doc)
+
+	prepared, err := PrepareImage(engine, path, steps, models.OCRConfig{}, "job")
+	if err != nil {
+		t.Fatalf("PrepareImage() error = %v", err)
+	}
+	defer prepared.Cleanup()
+	got, err := engine.Recognize(prepared.Path, models.OCRConfig{})
+	if err != nil {
+		t.Fatalf("Recognize() error = %v", err)
+	}
+	prepared.Unmap(got)
+
+	want := models.BBox{X0: 20, Y0: 20, X1: 180, Y1: 28}
+	gotPage := got.Pages[0]
+	if gotPage.Width != 200 || gotPage.Height != 120 {
+		t.Errorf("page size = %dx%d, want the original 200x120", gotPage.Width, gotPage.Height)
+	}
+	block := gotPage.Blocks[0]
+	if block.BBox != want || block.Lines[0].BBox != want || block.Lines[0].Words[0].BBox != want {
+		t.Errorf("boxes = %+v, %+v, %+v, want %+v", block.BBox, block.Lines[0].BBox, block.Lines[0].Words[0].BBox, want)
+	}
+}
@@ -0,0 +1,77 @@
+package ocr
+
+import (
+	"backend/models"
+	"backend/pkg/tiff"
+	"context"
+	"fmt"
+	"log"
+	"os"
+)
+
+// OCRPages recognizes the pages of a multi-page document, such as the files
+// tiff.Split writes, each prepared with steps as PrepareImage does, with
+// OCR_PAGE_WORKERS workers of engine. It returns one document with the pages in their
+// order and calls progress, if not nil, whenever a page is done.
+//
+// Every failed page is reported in the joined error, the document then holds
+// the other pages. Cancelling ctx stops the remaining pages.
+func OCRPages(ctx context.Context, engine OCREngine, pagePaths []string, steps []string, cfg models.OCRConfig, name string, progress func()) (*models.Document, error) {
+	pages, errs := recognizeConcurrent(ctx, len(pagePaths), workersFromEnv("OCR_PAGE_WORKERS"),
+		func(i int) (*models.Document, error) {
+			doc, err := recognizePage(ctx, engine, pagePaths[i], steps, cfg, fmt.Sprintf("%s_page_%d", name, i+1))
+			if progress != nil {
+				progress()
+			}
+			return doc, err
+		})
+
+	doc := &models.Document{}
+	for i, page := range pages {
+		if page == nil {
+			continue
+		}
+		page.Pages[0].Number = i + 1
+		doc.Pages = append(doc.Pages, page.Pages[0])
+	}
+	return doc, joinErrors("page", pagePaths, errs)
+}
+
+func recognizePage(ctx context.Context, engine OCREngine, pagePath string, steps []string, cfg models.OCRConfig, name string) (*models.Document, error) {
+	prepared, err := PrepareImage(engine, pagePath, steps, cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	defer prepared.Cleanup()
+
+	doc, err := engine.Recognize(prepared.Path, cfg)
+	if err != nil {
+		return nil, err
+	}
+	doc.Pages[0].Orientation = prepared.Orientation
+	doc.Pages[0].OrientationDetected = prepared.OrientationDetected
+	if err := RecognizeTables(ctx, engine, prepared.Path, &doc.Pages[0], cfg); err != nil {
+		log.Printf("Failed to recognize the tables of %s: %v", pagePath, err)
+	}
+	prepared.Unmap(doc)
+	return doc, nil
+}
+
+// pagesDir holds the pages split from multi-page files while they are
+// recognized
+const pagesDir = "pages/"
+
+// OCRTIFF recognizes every page of a TIFF file with OCRPages, the pages
+// are split into files of their own, removed afterwards
+func OCRTIFF(ctx context.Context, engine OCREngine, tiffPath string, steps []string, cfg models.OCRConfig, name string, progress func()) (*models.Document, error) {
+	pagePaths, err := tiff.Split(tiffPath, pagesDir, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to split TIFF pages: %w", err)
+	}
+	defer func() {
+		for _, path := range pagePaths {
+			os.Remove(path)
+		}
+	}()
+	return OCRPages(ctx, engine, pagePaths, steps, cfg, name, progress)
+}
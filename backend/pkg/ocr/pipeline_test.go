@@ -0,0 +1,295 @@
+package ocr
+
+import (
+	"backend/models"
+	"backend/pkg/preprocess"
+	"backend/pkg/segmentation"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// blankImage returns a white image
+func blankImage(width, height int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	return img
+}
+
+// fillRect paints r of img black
+func fillRect(img *image.Gray, r image.Rectangle) {
+	draw.Draw(img, r, image.NewUniform(color.Black), image.Point{}, draw.Src)
+}
+
+// saveImage writes img as preprocess.SaveTemp does, removed after the test
+func saveImage(t *testing.T, img *image.Gray) string {
+	t.Helper()
+	path, err := preprocess.SaveTemp(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(path) })
+	return path
+}
+
+// grayHash is the ImageHash of img once the pipeline saves it
+func grayHash(t *testing.T, img *image.Gray) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(sum[:])
+}
+
+// wordDoc is a document of one word at box with the confidence
+func wordDoc(text string, box models.BBox, confidence float64) *models.Document {
+	line := models.Line{BBox: box, Words: []models.Word{{BBox: box, Text: text, Confidence: confidence}}}
+	block := models.Block{BBox: box, Lines: []models.Line{line}, Text: text}
+	return &models.Document{Pages: []models.Page{{Number: 1, Blocks: []models.Block{block}}}}
+}
+
+func TestOCRDocumentConcurrent(t *testing.T) {
+	t.Setenv("OCR_SEGMENT_WORKERS", "2")
+	engine := NewFakeEngine()
+	var paths []string
+	texts := []string{"First segment", "", "Third one\n\nLast paragraph"}
+	for i, text := range texts {
+		img := blankImage(100, 40+i)
+		paths = append(paths, saveImage(t, img))
+		if text != "" {
+			engine.AddText(grayHash(t, img), text)
+		}
+	}
+
+	doc, err := OCRDocumentConcurrent(context.Background(), engine, paths, models.OCRConfig{})
+	if err == nil || !strings.Contains(err.Error(), "segment 1") {
+		t.Errorf("OCRDocumentConcurrent() error = %v, want segment 1 reported", err)
+	}
+	if got, want := doc.Text(), "First segment\n\nThird one\n\nLast paragraph"; got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+
+	// the fake gives every line and the blank line after each paragraph
+	// fakeLineHeight, the third segment is moved below the first, the
+	// failed one takes no room
+	page := doc.Pages[0]
+	if len(doc.Pages) != 1 || page.Height != 6*fakeLineHeight {
+		t.Fatalf("got %d pages of height %d", len(doc.Pages), page.Height)
+	}
+	if y := page.Blocks[1].Lines[0].Words[0].BBox.Y0; y != 2*fakeLineHeight {
+		t.Errorf("third segment starts at y = %d, want %d", y, 2*fakeLineHeight)
+	}
+	if y := page.Blocks[2].BBox.Y0; y != 4*fakeLineHeight {
+		t.Errorf("last paragraph starts at y = %d, want %d", y, 4*fakeLineHeight)
+	}
+}
+
+func TestOCRDocumentConcurrentCancelled(t *testing.T) {
+	engine := NewFakeEngine()
+	img := blankImage(10, 10)
+	engine.AddText(grayHash(t, img), "never read")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	doc, err := OCRDocumentConcurrent(ctx, engine, []string{saveImage(t, img)}, models.OCRConfig{})
+	if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Errorf("OCRDocumentConcurrent() error = %v, want %v", err, context.Canceled)
+	}
+	if text := doc.Text(); text != "" {
+		t.Errorf("Text() = %q after cancelling", text)
+	}
+}
+
+func TestOCRPages(t *testing.T) {
+	t.Setenv("OCR_PAGE_WORKERS", "3")
+	t.Setenv("OCR_DETECT_TABLES", "false")
+	engine := NewFakeEngine()
+	var paths []string
+	for i, text := range []string{"Page one", "Page two", "", "Page four"} {
+		img := blankImage(50, 50+i)
+		paths = append(paths, saveImage(t, img))
+		if text != "" {
+			engine.AddText(grayHash(t, img), text)
+		}
+	}
+
+	// the workers report their pages concurrently
+	var progress atomic.Int32
+	doc, err := OCRPages(context.Background(), engine, paths, nil, models.OCRConfig{}, "job", func() { progress.Add(1) })
+	if err == nil || !strings.Contains(err.Error(), "page 2") {
+		t.Errorf("OCRPages() error = %v, want page 2 reported", err)
+	}
+	if int(progress.Load()) != len(paths) {
+		t.Errorf("progress called %d times, want %d", progress.Load(), len(paths))
+	}
+	var numbers []int
+	for _, page := range doc.Pages {
+		numbers = append(numbers, page.Number)
+	}
+	if want := []int{1, 2, 4}; !slices.Equal(numbers, want) {
+		t.Errorf("page numbers = %v, want %v", numbers, want)
+	}
+	if got, want := doc.Text(), "Page one\n\nPage two\n\nPage four"; got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+}
+
+func TestPrepareImage(t *testing.T) {
+	// the page is stored upside down
+	upright := linesPage()
+	page := preprocess.Rotate(upright, 180)
+	path := saveImage(t, page)
+	engine := orientationEngine(t, upright)
+
+	tests := []struct {
+		name         string
+		steps        []string
+		temporary    bool
+		orientation  int
+		wantDetected bool
+		want         *image.Gray
+	}{
+		{name: "no steps", steps: nil},
+		{name: "binarized", steps: []string{preprocess.StepBinarize}, temporary: true, want: preprocess.Apply(page, []string{preprocess.StepBinarize}, nil)},
+		{name: "turned upright", steps: []string{preprocess.StepOrient}, temporary: true, orientation: 180, wantDetected: true, want: upright},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prepared, err := PrepareImage(engine, path, tt.steps, models.OCRConfig{}, "job")
+			if err != nil {
+				t.Fatalf("PrepareImage() error = %v", err)
+			}
+			if (prepared.Path != path) != tt.temporary {
+				t.Errorf("PrepareImage() path = %s, temporary = %v", prepared.Path, tt.temporary)
+			}
+			if prepared.Orientation != tt.orientation || prepared.OrientationDetected != tt.wantDetected {
+				t.Errorf("Orientation = %d, detected %v, want %d, detected %v", prepared.Orientation, prepared.OrientationDetected, tt.orientation, tt.wantDetected)
+			}
+			if tt.want != nil {
+				hash, err := ImageHash(prepared.Path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if hash != grayHash(t, tt.want) {
+					t.Error("the prepared image differs from the expected one")
+				}
+			}
+
+			prepared.Cleanup()
+			if _, err := os.Stat(prepared.Path); tt.temporary != os.IsNotExist(err) {
+				t.Errorf("after Cleanup, stat %s error = %v", prepared.Path, err)
+			}
+		})
+	}
+}
+
+func TestOCRRegions(t *testing.T) {
+	page := blankImage(200, 100)
+	fillRect(page, image.Rect(20, 20, 40, 30))
+	path := saveImage(t, page)
+	regions := []models.Region{
+		{Name: "name", X: 10, Y: 10, Width: 60, Height: 30},
+		{Name: "total", X: 0.5, Y: 0.5, Width: 0.25, Height: 0.5, Unit: models.RegionRelative},
+	}
+
+	// the regions are cropped and padded before they are recognized, a word
+	// at the padding is at the corner of the region
+	engine := NewFakeEngine()
+	word := models.BBox{X0: cellPadding, Y0: cellPadding, X1: cellPadding + 30, Y1: cellPadding + 10}
+	nameCrop := cropImage(page, image.Rect(10, 10, 70, 40), 0)
+	engine.Add(grayHash(t, cropImage(nameCrop, nameCrop.Rect, cellPadding)), wordDoc("Jane", word, 90))
+	totalCrop := cropImage(page, image.Rect(100, 50, 150, 100), 0)
+	engine.Add(grayHash(t, cropImage(totalCrop, totalCrop.Rect, cellPadding)), wordDoc("42", word, 80))
+
+	doc, err := OCRRegions(context.Background(), engine, path, regions, nil, models.OCRConfig{}, "job")
+	if err != nil {
+		t.Fatalf("OCRRegions() error = %v", err)
+	}
+	texts := doc.RegionTexts()
+	if texts["name"] != "Jane" || texts["total"] != "42" {
+		t.Errorf("RegionTexts() = %v", texts)
+	}
+	blocks := doc.Pages[0].Blocks
+	if len(blocks) != 2 || blocks[1].BBox != (models.BBox{X0: 100, Y0: 50, X1: 150, Y1: 100}) {
+		t.Fatalf("blocks = %+v", blocks)
+	}
+	if box := blocks[1].Lines[0].Words[0].BBox; box != (models.BBox{X0: 100, Y0: 50, X1: 130, Y1: 60}) {
+		t.Errorf("word of region total at %+v, want it in the page pixels", box)
+	}
+
+	outside := []models.Region{{Name: "far", X: 500, Y: 500, Width: 10, Height: 10}}
+	if _, err := OCRRegions(context.Background(), engine, path, outside, nil, models.OCRConfig{}, "job"); err == nil {
+		t.Error("OCRRegions() of a region outside the image succeeded")
+	}
+}
+
+func TestRecognizeTables(t *testing.T) {
+	// a ruled table of 2 rows and 3 columns, the last cell empty
+	page := blankImage(400, 300)
+	rows, cols := []int{50, 110, 170}, []int{50, 150, 250, 350}
+	for _, y := range rows {
+		fillRect(page, image.Rect(cols[0], y, cols[3]+2, y+2))
+	}
+	for _, x := range cols {
+		fillRect(page, image.Rect(x, rows[0], x+2, rows[2]+2))
+	}
+	for i := 0; i < 5; i++ {
+		x, y := cols[i%3]+20, rows[i/3]+20
+		fillRect(page, image.Rect(x, y, x+10+5*i, y+10))
+	}
+	path := saveImage(t, page)
+
+	tables := segmentation.DetectTables(page)
+	if len(tables) != 1 {
+		t.Fatalf("DetectTables() found %d tables", len(tables))
+	}
+	engine := NewFakeEngine()
+	for r := 0; r < 2; r++ {
+		for c := 0; c < 3; c++ {
+			if r == 1 && c == 2 {
+				continue
+			}
+			cell := cropImage(page, tables[0].Cell(r, c), cellPadding)
+			engine.AddText(grayHash(t, cell), string(rune('A'+3*r+c)))
+		}
+	}
+
+	// Tesseract read across the table, and below it
+	pageDoc := &models.Page{Blocks: []models.Block{
+		{BBox: models.BBox{X0: 60, Y0: 60, X1: 340, Y1: 160}, Text: "A B C D E"},
+		{BBox: models.BBox{X0: 50, Y0: 200, X1: 350, Y1: 220}, Text: "Below the table"},
+	}}
+	if err := RecognizeTables(context.Background(), engine, path, pageDoc, models.OCRConfig{}); err != nil {
+		t.Fatalf("RecognizeTables() error = %v", err)
+	}
+	if len(pageDoc.Blocks) != 2 || pageDoc.Blocks[0].Table == nil || pageDoc.Blocks[1].Text != "Below the table" {
+		t.Fatalf("blocks = %+v", pageDoc.Blocks)
+	}
+	var cells []string
+	for _, row := range pageDoc.Blocks[0].Table.Rows {
+		for _, cell := range row {
+			cells = append(cells, cell.Text)
+		}
+	}
+	if got := strings.Join(cells, ","); got != "A,B,C,D,E," {
+		t.Errorf("cells = %q, want %q", got, "A,B,C,D,E,")
+	}
+
+	t.Setenv("OCR_DETECT_TABLES", "false")
+	untouched := &models.Page{Blocks: []models.Block{{Text: "as read"}}}
+	if err := RecognizeTables(context.Background(), NewFakeEngine(), path, untouched, models.OCRConfig{}); err != nil || untouched.Blocks[0].Table != nil {
+		t.Errorf("RecognizeTables() with OCR_DETECT_TABLES=false changed the page: %v", err)
+	}
+}
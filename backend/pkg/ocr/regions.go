@@ -0,0 +1,135 @@
+package ocr
+
+import (
+	"backend/models"
+	"backend/pkg/preprocess"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+)
+
+// MaxRegions bounds the regions of interest of one upload
+const MaxRegions = 50
+
+// ParseRegions reads the regions upload parameter, a JSON list of named
+// rectangles such as
+//
+//	[{"name":"address","x":120,"y":80,"width":600,"height":200},
+//	 {"name":"total","x":0.6,"y":0.85,"width":0.3,"height":0.1,"unit":"relative"}]
+//
+// Empty means the whole page.
+func ParseRegions(value string) ([]models.Region, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var regions []models.Region
+	if err := json.Unmarshal([]byte(value), &regions); err != nil {
+		return nil, fmt.Errorf("invalid regions: %v", err)
+	}
+	if len(regions) > MaxRegions {
+		return nil, fmt.Errorf("at most %d regions are allowed", MaxRegions)
+	}
+
+	names := map[string]bool{}
+	for _, region := range regions {
+		if region.Name == "" {
+			return nil, fmt.Errorf("every region needs a name")
+		}
+		if names[region.Name] {
+			return nil, fmt.Errorf("duplicate region name: %s", region.Name)
+		}
+		names[region.Name] = true
+
+		if region.X < 0 || region.Y < 0 || region.Width <= 0 || region.Height <= 0 {
+			return nil, fmt.Errorf("region %s: the position must not be negative and the size must be positive", region.Name)
+		}
+		switch region.Unit {
+		case "", models.RegionPixels:
+		case models.RegionRelative:
+			if region.X+region.Width > 1 || region.Y+region.Height > 1 {
+				return nil, fmt.Errorf("region %s: relative coordinates must lie within 0 and 1", region.Name)
+			}
+		default:
+			return nil, fmt.Errorf("region %s: unsupported unit %s", region.Name, region.Unit)
+		}
+	}
+	return regions, nil
+}
+
+// OCRRegions recognizes only the regions of the image at imagePath, with
+// OCR_SEGMENT_WORKERS workers of engine. Each region is cropped from the
+// image, upright as its EXIF orientation says, and cleaned up with steps on
+// its own; StepOrient is skipped, the regions refer to the upright image.
+//
+// The document has one page with a block per region, in their order, named
+// by Block.Region. Every failed region is reported in the joined error, the
+// document then holds the other regions.
+func OCRRegions(ctx context.Context, engine OCREngine, imagePath string, regions []models.Region, steps []string, cfg models.OCRConfig, name string) (*models.Document, error) {
+	img, err := preprocess.Load(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+
+	names := make([]string, len(regions))
+	for i, region := range regions {
+		names[i] = region.Name
+		if region.Bounds(width, height).Empty() {
+			return nil, fmt.Errorf("region %s lies outside the %dx%d image", region.Name, width, height)
+		}
+	}
+
+	blocks, errs := recognizeConcurrent(ctx, len(regions), workersFromEnv("OCR_SEGMENT_WORKERS"),
+		func(i int) (*models.Document, error) {
+			return recognizeRegion(engine, img, regions[i], steps, cfg, fmt.Sprintf("%s_region_%d", name, i+1))
+		})
+
+	page := models.Page{Number: 1, Width: width, Height: height}
+	for _, block := range blocks {
+		if block != nil {
+			page.Blocks = append(page.Blocks, block.Pages[0].Blocks...)
+		}
+	}
+	return &models.Document{Pages: []models.Page{page}}, joinErrors("region", names, errs)
+}
+
+// recognizeRegion returns a document of the single block of the region,
+// its boxes in the pixels of img
+func recognizeRegion(engine OCREngine, img *image.Gray, region models.Region, steps []string, cfg models.OCRConfig, name string) (*models.Document, error) {
+	bounds := toRect(region.Bounds(img.Rect.Dx(), img.Rect.Dy()))
+	processed, geometry := preprocess.ApplyGeometry(cropImage(img, bounds, 0), steps, preprocess.DebugSaver(name))
+	path, err := preprocess.SaveTemp(cropImage(processed, processed.Rect, cellPadding))
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(path)
+
+	doc, err := engine.Recognize(path, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// upscaling and deskewing move the text of the region, the boxes are
+	// moved back
+	place := func(box models.BBox) models.BBox {
+		r := toRect(box).Sub(image.Pt(cellPadding, cellPadding))
+		return toBBox(geometry.UnmapRect(r).Add(bounds.Min))
+	}
+
+	block := models.Block{Region: region.Name, BBox: toBBox(bounds), Text: doc.Text()}
+	for _, recognized := range doc.Blocks() {
+		for _, line := range recognized.Lines {
+			line.BBox = place(line.BBox)
+			words := make([]models.Word, len(line.Words))
+			for w, word := range line.Words {
+				word.BBox = place(word.BBox)
+				words[w] = word
+			}
+			line.Words = words
+			block.Lines = append(block.Lines, line)
+		}
+	}
+	return &models.Document{Pages: []models.Page{{Number: 1, Blocks: []models.Block{block}}}}, nil
+}
@@ -0,0 +1,71 @@
+package ocr
+
+import (
+	"backend/models"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRegions(t *testing.T) {
+	tooMany := make([]string, MaxRegions+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf(`{"name":"r%d","x":0,"y":0,"width":1,"height":1}`, i)
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    []models.Region
+		wantErr string
+	}{
+		{name: "none", value: ""},
+		{
+			name:  "pixels",
+			value: `[{"name":"address","x":120,"y":80,"width":600,"height":200}]`,
+			want:  []models.Region{{Name: "address", X: 120, Y: 80, Width: 600, Height: 200}},
+		},
+		{
+			name:  "relative",
+			value: `[{"name":"total","x":0.6,"y":0.85,"width":0.3,"height":0.1,"unit":"relative"},{"name":"date","x":0,"y":0,"width":1,"height":1,"unit":"px"}]`,
+			want: []models.Region{
+				{Name: "total", X: 0.6, Y: 0.85, Width: 0.3, Height: 0.1, Unit: models.RegionRelative},
+				{Name: "date", X: 0, Y: 0, Width: 1, Height: 1, Unit: models.RegionPixels},
+			},
+		},
+		{name: "not json", value: `{"name":"a"}`, wantErr: "invalid regions"},
+		{name: "too many", value: "[" + strings.Join(tooMany, ",") + "]", wantErr: "at most"},
+		{name: "no name", value: `[{"x":0,"y":0,"width":10,"height":10}]`, wantErr: "needs a name"},
+		{
+			name:    "duplicate name",
+			value:   `[{"name":"a","x":0,"y":0,"width":10,"height":10},{"name":"a","x":5,"y":5,"width":10,"height":10}]`,
+			wantErr: "duplicate region name: a",
+		},
+		{name: "negative position", value: `[{"name":"a","x":-1,"y":0,"width":10,"height":10}]`, wantErr: "must not be negative"},
+		{name: "zero size", value: `[{"name":"a","x":0,"y":0,"width":0,"height":10}]`, wantErr: "must be positive"},
+		{
+			name:    "relative beyond the image",
+			value:   `[{"name":"a","x":0.5,"y":0,"width":0.6,"height":0.5,"unit":"relative"}]`,
+			wantErr: "within 0 and 1",
+		},
+		{name: "unknown unit", value: `[{"name":"a","x":0,"y":0,"width":1,"height":1,"unit":"mm"}]`, wantErr: "unsupported unit mm"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regions, err := ParseRegions(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseRegions() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRegions() error = %v", err)
+			}
+			if !reflect.DeepEqual(regions, tt.want) {
+				t.Errorf("ParseRegions() = %+v, want %+v", regions, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package ocr
+
+import (
+	"backend/models"
+	"backend/pkg/preprocess"
+	"backend/pkg/segmentation"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"slices"
+	"strings"
+)
+
+// cellPageSegMode is Tesseract's --psm for cells, a single block of text
+const cellPageSegMode = 6
+
+// cellPadding is the white border, in pixels, around a cell image,
+// Tesseract reads text touching the edge of an image poorly
+const cellPadding = 10
+
+// TablesEnabled reports whether ruled tables are recognized cell by cell,
+// unless OCR_DETECT_TABLES is false
+func TablesEnabled() bool {
+	return os.Getenv("OCR_DETECT_TABLES") != "false"
+}
+
+// RecognizeTables finds the ruled tables of the page image at imagePath,
+// see segmentation.DetectTables, and recognizes their cells one by one with
+// OCR_TABLE_WORKERS workers of engine. Each table replaces the blocks of
+// page Tesseract read across it, which jumble the columns.
+//
+// A table whose cells fail is left out and reported in the joined error,
+// the page then keeps the blocks of its region.
+func RecognizeTables(ctx context.Context, engine OCREngine, imagePath string, page *models.Page, cfg models.OCRConfig) error {
+	if !TablesEnabled() {
+		return nil
+	}
+	img, err := preprocess.Load(imagePath)
+	if errors.Is(err, image.ErrFormat) {
+		// a TIFF page only Tesseract can read
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if cfg.PageSegMode == nil {
+		psm := cellPageSegMode
+		cfg.PageSegMode = &psm
+	}
+	var failures []error
+	for i, found := range segmentation.DetectTables(img) {
+		table, err := recognizeTable(ctx, engine, img, found, cfg)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("table %d: %w", i+1, err))
+			continue
+		}
+		insertTable(page, table, toBBox(found.Bounds))
+	}
+	return errors.Join(failures...)
+}
+
+func recognizeTable(ctx context.Context, engine OCREngine, img *image.Gray, found segmentation.Table, cfg models.OCRConfig) (*models.Table, error) {
+	table := &models.Table{Rows: make([][]models.Cell, len(found.Rows)-1)}
+	var names []string
+	var cells []*models.Cell
+	for r := range table.Rows {
+		table.Rows[r] = make([]models.Cell, len(found.Cols)-1)
+		for c := range table.Rows[r] {
+			cell := &table.Rows[r][c]
+			bounds := found.Cell(r, c)
+			cell.BBox = toBBox(bounds)
+			if hasInk(img, bounds) {
+				names = append(names, fmt.Sprintf("row %d column %d", r+1, c+1))
+				cells = append(cells, cell)
+			}
+		}
+	}
+
+	docs, errs := recognizeConcurrent(ctx, len(cells), workersFromEnv("OCR_TABLE_WORKERS"),
+		func(i int) (*models.Document, error) {
+			path, err := preprocess.SaveTemp(cropImage(img, toRect(cells[i].BBox), cellPadding))
+			if err != nil {
+				return nil, err
+			}
+			defer os.Remove(path)
+
+			return engine.Recognize(path, cfg)
+		})
+	if err := joinErrors("cell", names, errs); err != nil {
+		return nil, err
+	}
+	for i, doc := range docs {
+		// a cell is short, its lines are joined
+		cells[i].Text = strings.Join(strings.Fields(doc.Text()), " ")
+	}
+	return table, nil
+}
+
+// insertTable replaces the blocks centered in bounds with a block holding
+// the table, where the first of them was or, if Tesseract found no text
+// there, before the first block below its top
+func insertTable(page *models.Page, table *models.Table, bounds models.BBox) {
+	block := models.Block{BBox: bounds, Table: table, Text: table.Text()}
+	kept := make([]models.Block, 0, len(page.Blocks))
+	at := -1
+	for _, b := range page.Blocks {
+		x, y := (b.BBox.X0+b.BBox.X1)/2, (b.BBox.Y0+b.BBox.Y1)/2
+		if x >= bounds.X0 && x < bounds.X1 && y >= bounds.Y0 && y < bounds.Y1 {
+			if at < 0 {
+				at = len(kept)
+			}
+			block.Lines = append(block.Lines, b.Lines...)
+			continue
+		}
+		kept = append(kept, b)
+	}
+	if at < 0 {
+		at = len(kept)
+		for i, b := range kept {
+			if b.BBox.Y0 > bounds.Y0 {
+				at = i
+				break
+			}
+		}
+	}
+	page.Blocks = slices.Insert(kept, at, block)
+}
+
+// hasInk reports whether the cell has any dark pixel, empty cells are not
+// recognized
+func hasInk(img *image.Gray, bounds image.Rectangle) bool {
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if img.GrayAt(x, y).Y < 128 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cropImage copies bounds of img onto a white image with a border of
+// padding pixels
+func cropImage(img *image.Gray, bounds image.Rectangle, padding int) *image.Gray {
+	crop := image.NewGray(image.Rect(0, 0, bounds.Dx()+2*padding, bounds.Dy()+2*padding))
+	draw.Draw(crop, crop.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(crop, bounds.Sub(bounds.Min).Add(image.Pt(padding, padding)), img, bounds.Min, draw.Src)
+	return crop
+}
+
+func toBBox(r image.Rectangle) models.BBox {
+	return models.BBox{X0: r.Min.X, Y0: r.Min.Y, X1: r.Max.X, Y1: r.Max.Y}
+}
+
+func toRect(b models.BBox) image.Rectangle {
+	return image.Rect(b.X0, b.Y0, b.X1, b.Y1)
+}
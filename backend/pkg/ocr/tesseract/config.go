@@ -0,0 +1,88 @@
+package tesseract
+
+import (
+	"backend/models"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/otiai10/gosseract/v2"
+)
+
+// InstalledLanguages lists the Tesseract languages installed on this host
+func InstalledLanguages() ([]string, error) {
+	installed, err := gosseract.GetAvailableLanguages()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tesseract languages: %v", err)
+	}
+	return installed, nil
+}
+
+// CheckLanguages fails unless every language of cfg is installed on this
+// host
+func CheckLanguages(cfg models.OCRConfig) error {
+	if len(cfg.LanguageList()) == 0 {
+		return nil
+	}
+	installed, err := InstalledLanguages()
+	if err != nil {
+		return err
+	}
+	return cfg.CheckInstalled(installed)
+}
+
+// newClient creates a Tesseract client set up for cfg
+func newClient(cfg models.OCRConfig) (*gosseract.Client, error) {
+	if err := CheckLanguages(cfg); err != nil {
+		return nil, err
+	}
+	client := gosseract.NewClient()
+	if err := applyConfig(client, cfg); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+func applyConfig(client *gosseract.Client, cfg models.OCRConfig) error {
+	if langs := cfg.LanguageList(); len(langs) > 0 {
+		if err := client.SetLanguage(langs...); err != nil {
+			return fmt.Errorf("failed to set language: %v", err)
+		}
+	}
+	if cfg.PageSegMode != nil {
+		if err := client.SetPageSegMode(gosseract.PageSegMode(*cfg.PageSegMode)); err != nil {
+			return fmt.Errorf("failed to set page segmentation mode: %v", err)
+		}
+	}
+	if cfg.EngineMode != nil {
+		// gosseract has no setter for the engine mode, it is only read
+		// from a config file when the API is initialized
+		path, err := engineModeConfigFile(*cfg.EngineMode)
+		if err != nil {
+			return err
+		}
+		if err := client.SetConfigFile(path); err != nil {
+			return fmt.Errorf("failed to set OCR engine mode: %v", err)
+		}
+	}
+	for name, value := range cfg.Variables {
+		if err := client.SetVariable(gosseract.SettableVariable(name), value); err != nil {
+			return fmt.Errorf("failed to set variable %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// engineModeConfigFile returns a Tesseract config file that selects mode
+func engineModeConfigFile(mode int) (string, error) {
+	path := filepath.Join(os.TempDir(), fmt.Sprintf("ocr-oem-%d.config", mode))
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	err := os.WriteFile(path, []byte(fmt.Sprintf("tessedit_ocr_engine_mode %d\n", mode)), 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to write engine mode config: %v", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,23 @@
+package tesseract
+
+import (
+	"backend/models"
+)
+
+// Engine is the ocr.OCREngine of Tesseract. A pooled engine takes its
+// clients from the pool of Initialize, the others create a client for every
+// image.
+type Engine struct {
+	Pooled bool
+}
+
+func NewEngine(pooled bool) *Engine {
+	return &Engine{Pooled: pooled}
+}
+
+func (e *Engine) Recognize(imagePath string, cfg models.OCRConfig) (*models.Document, error) {
+	if e.Pooled {
+		return OCRDocument(imagePath, cfg)
+	}
+	return OneShotOCRDocument(imagePath, cfg)
+}
@@ -0,0 +1,54 @@
+package tesseract
+
+import (
+	"backend/models"
+	"backend/pkg/ocr"
+	"strings"
+
+	"github.com/otiai10/gosseract/v2"
+)
+
+// recognizeDocument runs the recognition on the image set on client
+func recognizeDocument(client *gosseract.Client) (*models.Document, error) {
+	boxes, err := client.GetBoundingBoxesVerbose()
+	if err != nil {
+		return nil, err
+	}
+	return buildDocument(boxes), nil
+}
+
+// buildDocument groups word boxes, which Tesseract returns in reading order,
+// into paragraphs and lines by their block, paragraph and line numbers.
+// The document has a single page.
+func buildDocument(boxes []gosseract.BoundingBox) *models.Document {
+	page := models.Page{Number: 1}
+	lastBlock, lastPar, lastLine := -1, -1, -1
+	for _, box := range boxes {
+		text := strings.TrimSpace(box.Word)
+		if text == "" {
+			continue
+		}
+
+		if box.BlockNum != lastBlock || box.ParNum != lastPar || len(page.Blocks) == 0 {
+			page.Blocks = append(page.Blocks, models.Block{})
+			lastLine = -1
+		}
+		block := &page.Blocks[len(page.Blocks)-1]
+		if box.LineNum != lastLine || len(block.Lines) == 0 {
+			block.Lines = append(block.Lines, models.Line{})
+		}
+		line := &block.Lines[len(block.Lines)-1]
+
+		bbox := models.BBox{X0: box.Box.Min.X, Y0: box.Box.Min.Y, X1: box.Box.Max.X, Y1: box.Box.Max.Y}
+		line.Words = append(line.Words, models.Word{BBox: bbox, Text: text, Confidence: box.Confidence})
+		line.BBox = line.BBox.Union(bbox)
+		block.BBox = block.BBox.Union(bbox)
+
+		lastBlock, lastPar, lastLine = box.BlockNum, box.ParNum, box.LineNum
+	}
+
+	for b := range page.Blocks {
+		page.Blocks[b].Text = ocr.ParagraphText(page.Blocks[b].Lines)
+	}
+	return &models.Document{Pages: []models.Page{page}}
+}
@@ -0,0 +1,286 @@
+package tesseract
+
+import (
+	"backend/models"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"maps"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/otiai10/gosseract/v2"
+)
+
+// ErrPoolClosed is returned for clients requested after Cleanup
+var ErrPoolClosed = errors.New("tesseract client pool closed")
+
+// clientPool holds the Tesseract clients of the process, idle ones by the
+// key of their config. At most size clients exist at once: when there are
+// size, a job either takes over the client idle longest, closed and created
+// again for its config, or waits for one to be released.
+type clientPool struct {
+	mu       sync.Mutex
+	released *sync.Cond
+	size     int
+	// open counts the clients created and not closed, idle or in use
+	open int
+	// idle holds the released clients, the one idle longest first
+	idle   []*pooledClient
+	closed bool
+}
+
+type pooledClient struct {
+	*gosseract.Client
+	key string
+	// settings are those of the config the client was created for, which
+	// it is reset to when released
+	settings clientSettings
+	// pageSegMode is the mode last set, by the config of the job using the
+	// client
+	pageSegMode gosseract.PageSegMode
+}
+
+// defaultPageSegMode is the page segmentation mode of a new TessBaseAPI,
+// gosseract does not change it (the tesseract command uses PSM_AUTO)
+const defaultPageSegMode = gosseract.PSM_SINGLE_BLOCK
+
+// poolKey is the key of the clients of cfg. The page segmentation mode is
+// set for every job, see setPageSegMode, so that the cells of a table,
+// recognized with another mode, share the clients of their page.
+func poolKey(cfg models.OCRConfig) string {
+	cfg.PageSegMode = nil
+	return cfg.Key()
+}
+
+// clientSettings are the fields of a client its config sets, gosseract
+// applies them when it next initializes the Tesseract API
+type clientSettings struct {
+	languages  []string
+	variables  map[gosseract.SettableVariable]string
+	configFile string
+}
+
+func settingsOf(client *gosseract.Client) clientSettings {
+	return clientSettings{
+		languages:  slices.Clone(client.Languages),
+		variables:  maps.Clone(client.Variables),
+		configFile: client.ConfigFilePath,
+	}
+}
+
+func (s clientSettings) equal(other clientSettings) bool {
+	return slices.Equal(s.languages, other.languages) && maps.Equal(s.variables, other.variables) && s.configFile == other.configFile
+}
+
+func newClientPool(size int) *clientPool {
+	p := &clientPool{size: max(size, 1)}
+	p.released = sync.NewCond(&p.mu)
+	return p
+}
+
+// get returns an idle client of cfg or a new one, set to the page
+// segmentation mode of cfg
+func (p *clientPool) get(cfg models.OCRConfig) (*pooledClient, error) {
+	client, err := p.take(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.setPageSegMode(cfg); err != nil {
+		p.put(client)
+		return nil, err
+	}
+	return client, nil
+}
+
+// take returns an idle client of the key of cfg or a new one, waiting while
+// the pool is full and every client is in use
+func (p *clientPool) take(cfg models.OCRConfig) (*pooledClient, error) {
+	key := poolKey(cfg)
+	p.mu.Lock()
+	for {
+		if p.closed {
+			p.mu.Unlock()
+			return nil, ErrPoolClosed
+		}
+		// the client released last is the most likely to be warm
+		for i := len(p.idle) - 1; i >= 0; i-- {
+			if client := p.idle[i]; client.key == key {
+				p.idle = slices.Delete(p.idle, i, i+1)
+				p.mu.Unlock()
+				return client, nil
+			}
+		}
+		if p.open < p.size {
+			p.open++
+			p.mu.Unlock()
+			withoutMode := cfg
+			withoutMode.PageSegMode = nil
+			client, err := newClient(withoutMode)
+			if err != nil {
+				p.mu.Lock()
+				p.open--
+				p.released.Signal()
+				p.mu.Unlock()
+				return nil, err
+			}
+			return &pooledClient{Client: client, key: key, settings: settingsOf(client), pageSegMode: defaultPageSegMode}, nil
+		}
+		if len(p.idle) > 0 {
+			// the pool is full of clients of other configs
+			evicted := p.idle[0]
+			p.idle = p.idle[1:]
+			p.open--
+			evicted.Close()
+			continue
+		}
+		p.released.Wait()
+	}
+}
+
+// put resets the client to the settings of its config and gives it back, a
+// client that can not be reset, or released after close, is closed
+func (p *clientPool) put(client *pooledClient) {
+	err := client.reset()
+	if err != nil {
+		log.Printf("Failed to reset Tesseract client, closing it: %v", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed || err != nil {
+		client.Close()
+		p.open--
+	} else {
+		p.idle = append(p.idle, client)
+	}
+	p.released.Signal()
+}
+
+// setPageSegMode sets the page segmentation mode of cfg, the default when
+// it has none
+func (c *pooledClient) setPageSegMode(cfg models.OCRConfig) error {
+	mode := defaultPageSegMode
+	if cfg.PageSegMode != nil {
+		mode = gosseract.PageSegMode(*cfg.PageSegMode)
+	}
+	if mode == c.pageSegMode {
+		return nil
+	}
+	if err := c.SetPageSegMode(mode); err != nil {
+		return fmt.Errorf("failed to set page segmentation mode: %v", err)
+	}
+	c.pageSegMode = mode
+	return nil
+}
+
+// reset undoes settings changed while the client was in use, so that the
+// next job of the same config does not inherit them
+func (c *pooledClient) reset() error {
+	if err := c.setPageSegMode(models.OCRConfig{}); err != nil {
+		return err
+	}
+	if settingsOf(c.Client).equal(c.settings) {
+		return nil
+	}
+	c.Variables = maps.Clone(c.settings.variables)
+	c.ConfigFilePath = c.settings.configFile
+	// setting the languages makes gosseract initialize the API again
+	if err := c.SetLanguage(c.settings.languages...); err != nil {
+		return fmt.Errorf("failed to set language: %v", err)
+	}
+	return nil
+}
+
+// close closes the idle clients and, as they are released, those in use;
+// waiting jobs get ErrPoolClosed
+func (p *clientPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for _, client := range p.idle {
+		client.Close()
+	}
+	p.open -= len(p.idle)
+	p.idle = nil
+	p.released.Broadcast()
+}
+
+// prewarm creates up to count clients of cfg, as many as the pool has room
+// for, and loads their language data
+func (p *clientPool) prewarm(cfg models.OCRConfig, count int) error {
+	var clients []*pooledClient
+	defer func() {
+		for _, client := range clients {
+			p.put(client)
+		}
+	}()
+
+	for len(clients) < count {
+		p.mu.Lock()
+		full := p.open >= p.size
+		p.mu.Unlock()
+		if full {
+			return nil
+		}
+		client, err := p.get(cfg)
+		if err != nil {
+			return err
+		}
+		clients = append(clients, client)
+		if err := warm(client.Client); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// warm recognizes a blank image, gosseract only initializes the Tesseract
+// API, loading the language data, on the first recognition
+func warm(client *gosseract.Client) error {
+	img := image.NewGray(image.Rect(0, 0, 32, 32))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return fmt.Errorf("failed to encode image: %v", err)
+	}
+	if err := client.SetImageFromBytes(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to set image: %v", err)
+	}
+	if _, err := client.Text(); err != nil {
+		return fmt.Errorf("failed to initialize Tesseract: %v", err)
+	}
+	return nil
+}
+
+// poolSettingsFromEnv reads the size of the pool, OCR_POOL_SIZE (by default
+// the number of CPUs), how many clients of each config are created at
+// startup, OCR_POOL_PREWARM (by default 1), and the language lists warmed
+// besides the default, OCR_POOL_LANGUAGES, e.g. "vie+eng,jpn". The clients
+// warmed for a language list also serve its table cells, see poolKey.
+func poolSettingsFromEnv() (size, prewarm int, configs []models.OCRConfig) {
+	size, err := strconv.Atoi(os.Getenv("OCR_POOL_SIZE"))
+	if err != nil || size < 1 {
+		size = runtime.NumCPU()
+	}
+	prewarm, err = strconv.Atoi(os.Getenv("OCR_POOL_PREWARM"))
+	if err != nil || prewarm < 0 {
+		prewarm = 1
+	}
+	configs = []models.OCRConfig{{}}
+	for _, languages := range strings.Split(os.Getenv("OCR_POOL_LANGUAGES"), ",") {
+		if languages = strings.TrimSpace(languages); languages != "" {
+			configs = append(configs, models.OCRConfig{Languages: languages})
+		}
+	}
+	return size, prewarm, configs
+}
@@ -0,0 +1,125 @@
+// Package tesseract recognizes images with Tesseract through gosseract, the
+// ocr.OCREngine of the workers and the sync server. It needs cgo and the
+// Tesseract and Leptonica libraries, the pipeline of package ocr does not.
+package tesseract
+
+import (
+	"backend/models"
+	"backend/pkg/tiff"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"os"
+
+	"github.com/otiai10/gosseract/v2"
+)
+
+var tesseractPool *clientPool
+
+// Initialize initializes the Tesseract pool and pre-warms its clients, see
+// poolSettingsFromEnv (call only once at startup)
+func Initialize() {
+	size, prewarm, configs := poolSettingsFromEnv()
+	tesseractPool = newClientPool(size)
+	for _, cfg := range configs {
+		if err := tesseractPool.prewarm(cfg, prewarm); err != nil {
+			log.Printf("Failed to pre-warm Tesseract clients for %q: %v", cfg.Languages, err)
+		}
+	}
+
+	fmt.Printf("Tesseract client pool initialized with up to %d clients\n", size)
+}
+
+// Cleanup closes the Tesseract clients (call on shutdown)
+func Cleanup() {
+	if tesseractPool != nil {
+		tesseractPool.close()
+	}
+	fmt.Println("Tesseract client pool closed")
+}
+
+func OneShotOCR(imagePath string, cfg models.OCRConfig) (string, error) {
+	doc, err := OneShotOCRDocument(imagePath, cfg)
+	if err != nil {
+		return "", err
+	}
+	return doc.Text(), nil
+}
+
+// OneShotOCRDocument is OneShotOCR returning the structured result
+func OneShotOCRDocument(imagePath string, cfg models.OCRConfig) (*models.Document, error) {
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	return recognizeImage(client, imagePath)
+}
+
+// OCRFilter processes OCR on a single image
+func OCRFilter(imagePath string, cfg models.OCRConfig) (string, error) {
+	doc, err := OCRDocument(imagePath, cfg)
+	if err != nil {
+		return "", err
+	}
+	return doc.Text(), nil
+}
+
+// OCRDocument is OCRFilter returning the structured result
+func OCRDocument(imagePath string, cfg models.OCRConfig) (*models.Document, error) {
+	client, release, err := acquireClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return recognizeImage(client, imagePath)
+}
+
+// acquireClient returns a pooled client set up for cfg and the function
+// giving it back. Without Initialize every image gets a client of its own.
+func acquireClient(cfg models.OCRConfig) (*gosseract.Client, func(), error) {
+	if tesseractPool == nil {
+		client, err := newClient(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, func() { client.Close() }, nil
+	}
+
+	client, err := tesseractPool.get(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client.Client, func() { tesseractPool.put(client) }, nil
+}
+
+func recognizeImage(client *gosseract.Client, imagePath string) (*models.Document, error) {
+	err := client.SetImage(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set image: %v", err)
+	}
+
+	doc, err := recognizeDocument(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract text: %v", err)
+	}
+
+	// the page size lets renderers place the boxes
+	if file, err := os.Open(imagePath); err == nil {
+		config, _, err := image.DecodeConfig(file)
+		if errors.Is(err, image.ErrFormat) {
+			file.Seek(0, io.SeekStart)
+			config, err = tiff.DecodeConfig(file)
+		}
+		if err == nil {
+			doc.Pages[0].Width, doc.Pages[0].Height = config.Width, config.Height
+		}
+		file.Close()
+	}
+	return doc, nil
+}
@@ -0,0 +1,155 @@
+package pdf
+
+import (
+	"backend/models"
+	"regexp"
+	"strings"
+
+	gofpdf "github.com/jung-kurt/gofpdf"
+)
+
+const (
+	bilingualFontSize   = 11
+	bilingualLineHeight = 6
+	bilingualGutter     = 8
+	bilingualBottom     = 20
+)
+
+// paragraphBreak matches the blank lines between paragraphs, the same
+// boundaries the translation chunks keep
+var paragraphBreak = regexp.MustCompile(`\n[ \t]*\n\s*`)
+
+func splitParagraphs(text string) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	return paragraphBreak.Split(text, -1)
+}
+
+// bilingualPairs returns the (original, translation) paragraph pairs. A
+// document pairs them exactly by block; for plain texts the provider may
+// have merged or split paragraphs, then the counts differ and the remainder
+// of the longer side is paired with empty cells.
+func bilingualPairs(original, translated string, doc *models.Document) [][2]string {
+	var pairs [][2]string
+	if doc != nil {
+		for _, block := range doc.Blocks() {
+			pairs = append(pairs, [2]string{block.Text, block.TranslatedText})
+		}
+		return pairs
+	}
+
+	originals := splitParagraphs(original)
+	translations := splitParagraphs(translated)
+	for i := 0; i < len(originals) || i < len(translations); i++ {
+		var pair [2]string
+		if i < len(originals) {
+			pair[0] = originals[i]
+		}
+		if i < len(translations) {
+			pair[1] = translations[i]
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs
+}
+
+// writeBilingual renders the paragraph pairs in two columns, each pair
+// starting on the same line
+func writeBilingual(pdf *gofpdf.Fpdf, pairs [][2]string, margins map[string]float64, opts Options) {
+	pdf.SetAutoPageBreak(false, bilingualBottom)
+	pdf.SetFont("DejaVu", "", bilingualFontSize)
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left := margins["left"]
+	columnWidth := (pageWidth - left - margins["right"] - bilingualGutter) / 2
+	rightColumn := left + columnWidth + bilingualGutter
+	bottom := pageHeight - bilingualBottom
+
+	header := func() {
+		pdf.SetY(margins["top"] - 2*bilingualLineHeight)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.SetX(left)
+		pdf.CellFormat(columnWidth, bilingualLineHeight, columnTitle("Original", opts.SourceLang), "B", 0, "L", false, 0, "")
+		pdf.SetX(rightColumn)
+		pdf.CellFormat(columnWidth, bilingualLineHeight, columnTitle("Translation", opts.TargetLang), "B", 1, "L", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetY(margins["top"])
+	}
+	header()
+
+	// pairs follow the document blocks, see bilingualPairs
+	var blocks []*models.Block
+	if opts.Document != nil && opts.HighlightBelow > 0 {
+		blocks = opts.Document.Blocks()
+	}
+	pageStarts := documentPageStarts(opts.Document)
+
+	for i, pair := range pairs {
+		var marks *wordMarks
+		if i < len(blocks) {
+			marks = newWordMarks(blocks[i], opts.HighlightBelow)
+		}
+		if pageStarts[i] && pdf.GetY() > margins["top"] {
+			pdf.AddPage()
+			header()
+		}
+		leftLines := pdf.SplitText(pair[0], columnWidth)
+		rightLines := pdf.SplitText(pair[1], columnWidth)
+		lines := len(leftLines)
+		if len(rightLines) > lines {
+			lines = len(rightLines)
+		}
+
+		// keep short paragraphs together, long ones flow over pages line by line
+		height := float64(lines) * bilingualLineHeight
+		if pdf.GetY()+height > bottom && pdf.GetY() > margins["top"] && height <= bottom-margins["top"] {
+			pdf.AddPage()
+			header()
+		}
+		for line := 0; line < lines; line++ {
+			if pdf.GetY()+bilingualLineHeight > bottom {
+				pdf.AddPage()
+				header()
+			}
+			y := pdf.GetY()
+			if line < len(leftLines) && marks != nil {
+				writeMarkedLine(pdf, left, y, bilingualLineHeight, leftLines[line], marks)
+			} else if line < len(leftLines) {
+				pdf.SetXY(left, y)
+				pdf.CellFormat(columnWidth, bilingualLineHeight, leftLines[line], "", 0, "L", false, 0, "")
+			}
+			if line < len(rightLines) {
+				pdf.SetXY(rightColumn, y)
+				pdf.CellFormat(columnWidth, bilingualLineHeight, rightLines[line], "", 0, "L", false, 0, "")
+			}
+			pdf.SetY(y + bilingualLineHeight)
+		}
+		pdf.SetY(pdf.GetY() + bilingualLineHeight/2)
+	}
+}
+
+// documentPageStarts returns the indexes, in doc.Blocks(), of the first
+// block of every page but the first, where the PDF starts a new page too
+func documentPageStarts(doc *models.Document) map[int]bool {
+	starts := map[int]bool{}
+	if doc == nil {
+		return starts
+	}
+	n := 0
+	for p, page := range doc.Pages {
+		if p > 0 && n > 0 {
+			starts[n] = true
+		}
+		n += len(page.Blocks)
+	}
+	return starts
+}
+
+func columnTitle(title, lang string) string {
+	if lang == "" {
+		return title
+	}
+	return title + " (" + lang + ")"
+}
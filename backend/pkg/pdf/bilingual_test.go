@@ -0,0 +1,123 @@
+package pdf
+
+import (
+	"backend/models"
+	"reflect"
+	"strings"
+	"testing"
+
+	gofpdf "github.com/jung-kurt/gofpdf"
+)
+
+var testMargins = map[string]float64{"left": 30, "top": 50, "right": 30}
+
+func TestBilingualPairs(t *testing.T) {
+	tests := []struct {
+		name                 string
+		original, translated string
+		doc                  *models.Document
+		want                 [][2]string
+	}{
+		{
+			name:       "same paragraphs",
+			original:   "Hello.\n\nGoodbye.\n",
+			translated: "Xin chào.\n \nTạm biệt.",
+			want:       [][2]string{{"Hello.", "Xin chào."}, {"Goodbye.", "Tạm biệt."}},
+		},
+		{
+			name:       "merged by the provider",
+			original:   "One.\n\nTwo.\n\nThree.",
+			translated: "Một. Hai.\n\nBa.",
+			want:       [][2]string{{"One.", "Một. Hai."}, {"Two.", "Ba."}, {"Three.", ""}},
+		},
+		{name: "nothing", want: nil},
+		{
+			name:     "document blocks",
+			original: "ignored",
+			doc: &models.Document{Pages: []models.Page{
+				{Blocks: []models.Block{{Text: "Hello.\n\nStill one block", TranslatedText: "Xin chào."}}},
+				{Blocks: []models.Block{{Text: "Page two"}}},
+			}},
+			want: [][2]string{{"Hello.\n\nStill one block", "Xin chào."}, {"Page two", ""}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bilingualPairs(tt.original, tt.translated, tt.doc); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("bilingualPairs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentPageStarts(t *testing.T) {
+	doc := &models.Document{Pages: []models.Page{
+		{Blocks: make([]models.Block, 2)},
+		{},
+		{Blocks: make([]models.Block, 1)},
+		{Blocks: make([]models.Block, 3)},
+	}}
+	if got, want := documentPageStarts(doc), map[int]bool{2: true, 3: true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("documentPageStarts() = %v, want %v", got, want)
+	}
+	if got := documentPageStarts(nil); len(got) != 0 {
+		t.Errorf("documentPageStarts(nil) = %v, want none", got)
+	}
+}
+
+func TestWriteBilingualPages(t *testing.T) {
+	// 37 lines fit between the header and the bottom margin
+	long := strings.TrimSuffix(strings.Repeat("A line.\n", 80), "\n")
+	tests := []struct {
+		name  string
+		pairs [][2]string
+		doc   *models.Document
+		pages int
+	}{
+		{name: "short", pairs: [][2]string{{"Hello.", "Xin chào."}, {"Bye.", "Tạm biệt."}}, pages: 1},
+		{name: "long paragraph flows over pages", pairs: [][2]string{{long, "Ngắn."}}, pages: 3},
+		{
+			name:  "a page per document page",
+			pairs: [][2]string{{"Page one.", "Trang một."}, {"Page two.", "Trang hai."}},
+			doc: &models.Document{Pages: []models.Page{
+				{Blocks: []models.Block{{Text: "Page one."}}},
+				{Blocks: []models.Block{{Text: "Page two."}}},
+			}},
+			pages: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdf := gofpdf.New("P", "mm", "A4", "")
+			pdf.AddPage()
+			pdf.AddUTF8Font("DejaVu", "", "../../fonts/DejaVuSans.ttf")
+			writeBilingual(pdf, tt.pairs, testMargins, Options{SourceLang: "en", TargetLang: "vi", Document: tt.doc})
+			if pdf.Err() {
+				t.Fatal(pdf.Error())
+			}
+			if pdf.PageCount() != tt.pages {
+				t.Errorf("PageCount() = %d, want %d", pdf.PageCount(), tt.pages)
+			}
+		})
+	}
+}
+
+func TestColumnTitle(t *testing.T) {
+	if got := columnTitle("Original", "en"); got != "Original (en)" {
+		t.Errorf("columnTitle() = %q", got)
+	}
+	if got := columnTitle("Translation", ""); got != "Translation" {
+		t.Errorf("columnTitle() without a language = %q", got)
+	}
+}
+
+func TestValidateLayout(t *testing.T) {
+	for _, layout := range []string{"", LayoutTranslated, LayoutBilingual, LayoutSearchable, LayoutSearchableTranslated} {
+		if err := ValidateLayout(layout); err != nil {
+			t.Errorf("ValidateLayout(%q) error = %v", layout, err)
+		}
+	}
+	if err := ValidateLayout("side-by-side"); err == nil {
+		t.Error("ValidateLayout() accepted an unknown layout")
+	}
+}
@@ -0,0 +1,86 @@
+package pdf
+
+import (
+	"backend/models"
+	"backend/pkg/ocr"
+	"strings"
+
+	gofpdf "github.com/jung-kurt/gofpdf"
+)
+
+// highlightColor is the background of low-confidence words
+var highlightColor = [3]int{255, 230, 120}
+
+// lowConfidence reports whether the word was recognized below the threshold,
+// words without a confidence are never flagged
+func lowConfidence(word models.Word, below float64) bool {
+	return word.Confidence >= 0 && word.Confidence < below
+}
+
+// hasLowConfidence reports whether any word of the block is below the threshold
+func hasLowConfidence(block *models.Block, below float64) bool {
+	for _, line := range block.Lines {
+		for _, word := range line.Words {
+			if lowConfidence(word, below) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wordMarks matches the words of a block's text, in order, to its OCR words
+type wordMarks struct {
+	words []models.Word
+	next  int
+	below float64
+}
+
+// lookahead is how far a token is searched among the OCR words, so that a
+// token matching none does not stop the marking of the rest of the block
+const lookahead = 3
+
+func newWordMarks(block *models.Block, below float64) *wordMarks {
+	marks := &wordMarks{below: below}
+	for _, line := range block.Lines {
+		marks.words = append(marks.words, line.Words...)
+	}
+	return marks
+}
+
+// low consumes the OCR words behind token and reports whether any of them is
+// below the threshold. A word broken across two lines is one token, with or
+// without its hyphen.
+func (m *wordMarks) low(token string) bool {
+	for i := m.next; i < len(m.words) && i < m.next+lookahead; i++ {
+		word := m.words[i]
+		if word.Text == token {
+			m.next = i + 1
+			return lowConfidence(word, m.below)
+		}
+		trimmed := strings.TrimRight(word.Text, ocr.Hyphens)
+		if trimmed != word.Text && i+1 < len(m.words) && (token == trimmed+m.words[i+1].Text || token == word.Text+m.words[i+1].Text) {
+			m.next = i + 2
+			return lowConfidence(word, m.below) || lowConfidence(m.words[i+1], m.below)
+		}
+	}
+	return false
+}
+
+// writeMarkedLine writes one line of a block word by word at x, y with the
+// low-confidence words highlighted
+func writeMarkedLine(pdf *gofpdf.Fpdf, x, y, height float64, text string, marks *wordMarks) {
+	cellMargin := pdf.GetCellMargin()
+	pdf.SetCellMargin(0)
+	defer pdf.SetCellMargin(cellMargin)
+
+	pdf.SetFillColor(highlightColor[0], highlightColor[1], highlightColor[2])
+	space := pdf.GetStringWidth(" ")
+	x += cellMargin
+	for _, token := range strings.Fields(text) {
+		width := pdf.GetStringWidth(token)
+		pdf.SetXY(x, y)
+		pdf.CellFormat(width, height, token, "", 0, "L", marks.low(token), 0, "")
+		x += width + space
+	}
+}
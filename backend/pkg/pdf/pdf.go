@@ -1,6 +1,7 @@
 package pdf
 
 import (
+	"backend/models"
 	"fmt"
 	"bytes"
 	"io/ioutil"
@@ -9,17 +10,110 @@ import (
 	gofpdf "github.com/jung-kurt/gofpdf"
 )
 
-func ExportPDF(translatedText, jobID string, margins map[string]float64) (string, error) {
+const (
+	// LayoutTranslated renders the translation only
+	LayoutTranslated = "translated"
+	// LayoutBilingual renders the original text next to its translation
+	LayoutBilingual = "bilingual"
+	// LayoutSearchable renders the scanned page with the recognized text
+	// invisibly over it, LayoutSearchableTranslated with the translation
+	LayoutSearchable           = "searchable"
+	LayoutSearchableTranslated = "searchable_translated"
+)
+
+// Options select what ExportPDF and ExportPDFtoS3 render
+type Options struct {
+	// Layout is LayoutTranslated (the default) or LayoutBilingual
+	Layout string
+	// OriginalText, SourceLang and TargetLang are used by LayoutBilingual
+	OriginalText string
+	SourceLang   string
+	TargetLang   string
+	// Document, if set, supplies the paragraphs instead of the plain texts
+	Document *models.Document
+	// HighlightBelow highlights the words of Document recognized with a
+	// lower confidence (0-100), zero disables it
+	HighlightBelow float64
+	// ImagePath is the uploaded image, the page of the searchable layouts
+	ImagePath string
+}
+
+// ValidateLayout checks an output layout requested on upload, empty means
+// LayoutTranslated
+func ValidateLayout(layout string) error {
+	switch layout {
+	case "", LayoutTranslated, LayoutBilingual, LayoutSearchable, LayoutSearchableTranslated:
+		return nil
+	default:
+		return fmt.Errorf("unsupported output layout: %s", layout)
+	}
+}
+
+// newPDF lays out the document for opts
+func newPDF(translatedText string, margins map[string]float64, opts Options) *gofpdf.Fpdf {
+	// the searchable layouts need the OCR boxes, older jobs fall back to
+	// the translated text
+	if opts.Document != nil && (opts.Layout == LayoutSearchable || opts.Layout == LayoutSearchableTranslated) {
+		return newSearchablePDF(opts)
+	}
+
 	pdf := gofpdf.New("P", "mm", "A4", "")
 	pdf.AddPage()
 	pdf.SetMargins(margins["left"], margins["top"], margins["right"])
 
 	pdf.AddUTF8Font("DejaVu", "", "./fonts/DejaVuSans.ttf")
-	pdf.SetFont("DejaVu", "", 14)
 
+	if opts.Layout == LayoutBilingual {
+		writeBilingual(pdf, bilingualPairs(opts.OriginalText, translatedText, opts.Document), margins, opts)
+		return pdf
+	}
+
+	pdf.SetFont("DejaVu", "", 14)
 	pdf.MoveTo(0, 20)
 	width, _ := pdf.GetPageSize()
+	if opts.Document != nil {
+		writeTranslatedDocument(pdf, opts.Document, width, 10, opts.HighlightBelow)
+		return pdf
+	}
 	pdf.MultiCell(width, 10, translatedText, "", "", false)
+	return pdf
+}
+
+// writeTranslatedDocument renders the translated paragraphs and tables, each
+// page of the document on a new page. A translation does not map back to the OCR
+// words, so with highlightBelow set the paragraphs holding a low-confidence
+// word are highlighted as a whole.
+func writeTranslatedDocument(pdf *gofpdf.Fpdf, doc *models.Document, width, lineHeight, highlightBelow float64) {
+	pdf.SetFillColor(highlightColor[0], highlightColor[1], highlightColor[2])
+	pageStarts := documentPageStarts(doc)
+	first := true
+	for i, block := range doc.Blocks() {
+		if pageStarts[i] && !first {
+			pdf.AddPage()
+			pdf.MoveTo(0, 20)
+			first = true
+		}
+		if block.TranslatedText == "" {
+			continue
+		}
+		if !first {
+			pdf.Ln(lineHeight)
+		}
+		first = false
+		if block.Table != nil {
+			left, _, right, _ := pdf.GetMargins()
+			writeTable(pdf, block.Table, left, width-left-right)
+			continue
+		}
+		highlight := highlightBelow > 0 && hasLowConfidence(block, highlightBelow)
+		// the text spans the page like the plain text layout
+		pdf.SetX(0)
+		pdf.MultiCell(width, lineHeight, block.TranslatedText, "", "", highlight)
+	}
+}
+
+func ExportPDF(translatedText, jobID string, margins map[string]float64, opts Options) (string, error) {
+	pdf := newPDF(translatedText, margins, opts)
 	OutFilePath := fmt.Sprintf("./output/%s.pdf", jobID)
 	err := pdf.OutputFileAndClose(OutFilePath)
 
@@ -33,18 +127,9 @@ func ExportPDF(translatedText, jobID string, margins map[string]float64) (string
 
 
 // ExportPDFtoS3 generates a PDF and uploads it to S3 using a presigned URL
-func ExportPDFtoS3(translatedText, jobID string, margins map[string]float64, presignURL string) (string, error) {
+func ExportPDFtoS3(translatedText, jobID string, margins map[string]float64, presignURL string, opts Options) (string, error) {
 	// Generate the PDF content as a buffer
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	pdf.AddPage()
-	pdf.SetMargins(margins["left"], margins["top"], margins["right"])
-
-	pdf.AddUTF8Font("DejaVu", "", "./fonts/DejaVuSans.ttf")
-	pdf.SetFont("DejaVu", "", 14)
-
-	pdf.MoveTo(0, 20)
-	width, _ := pdf.GetPageSize()
-	pdf.MultiCell(width, 10, translatedText, "", "", false)
+	pdf := newPDF(translatedText, margins, opts)
 
 	// Save PDF content to a buffer instead of file
 	var buf bytes.Buffer
@@ -0,0 +1,200 @@
+package pdf
+
+import (
+	"backend/models"
+	"backend/pkg/preprocess"
+	"backend/pkg/tiff"
+	"bytes"
+	"fmt"
+	"image/jpeg"
+	"log"
+	"os"
+
+	gofpdf "github.com/jung-kurt/gofpdf"
+)
+
+const (
+	// searchablePageWidth is the width of the pages of a searchable PDF in
+	// mm, A4; the height follows the image
+	searchablePageWidth = 210.0
+	// textInvisible and textVisible are PDF text rendering modes
+	textInvisible = 3
+	textVisible   = 0
+	ptPerMM       = 72 / 25.4
+)
+
+// newSearchablePDF renders every page of the document as its scanned image
+// with the text placed invisibly over it at the OCR boxes, so that the PDF
+// can be searched and copied from. LayoutSearchable places the recognized
+// words, LayoutSearchableTranslated the translated paragraphs.
+//
+// The page images are loaded from opts.ImagePath as uploaded, only turned
+// upright; the OCR boxes are in its pixels, see ocr.PreparedImage.Unmap. A
+// page whose image can not be decoded shows its text instead.
+func newSearchablePDF(opts Options) *gofpdf.Fpdf {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8Font("DejaVu", "", "./fonts/DejaVuSans.ttf")
+	pdf.SetFont("DejaVu", "", 10)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetMargins(0, 0, 0)
+
+	imagePaths, cleanup := pageImagePaths(opts)
+	defer cleanup()
+
+	for p := range opts.Document.Pages {
+		page := &opts.Document.Pages[p]
+		imageName := ""
+		if imagePaths[p] != "" {
+			var err error
+			imageName, err = registerPageImage(pdf, imagePaths[p], page)
+			if err != nil {
+				log.Printf("Failed to embed the page image, rendering its text: %v", err)
+			}
+		}
+
+		height := searchablePageWidth * 297 / 210
+		if page.Width > 0 && page.Height > 0 {
+			height = searchablePageWidth * float64(page.Height) / float64(page.Width)
+		}
+		pdf.AddPageFormat("P", gofpdf.SizeType{Wd: searchablePageWidth, Ht: height})
+
+		mode := textVisible
+		if imageName != "" {
+			pdf.ImageOptions(imageName, 0, 0, searchablePageWidth, height, false, gofpdf.ImageOptions{ImageType: "JPEG"}, 0, "")
+			mode = textInvisible
+		}
+		// the rendering mode is reset on every page
+		pdf.SetTextRenderingMode(mode)
+
+		scale := searchablePageWidth / float64(max(page.Width, 1))
+		if opts.Layout == LayoutSearchableTranslated {
+			writeBlockLayer(pdf, page, scale)
+		} else {
+			writeWordLayer(pdf, page, scale)
+		}
+	}
+	return pdf
+}
+
+// pageImagePaths returns the image of every page of the document, empty
+// for pages without one. The pages of a TIFF are split into a temporary
+// directory, removed by cleanup, and found by their page number.
+func pageImagePaths(opts Options) ([]string, func()) {
+	paths := make([]string, len(opts.Document.Pages))
+	cleanup := func() {}
+	if opts.ImagePath == "" {
+		return paths, cleanup
+	}
+	if !tiff.IsTIFF(opts.ImagePath) {
+		if len(paths) == 1 {
+			paths[0] = opts.ImagePath
+		}
+		return paths, cleanup
+	}
+
+	dir, err := os.MkdirTemp("", "pdf-pages-*")
+	if err != nil {
+		log.Printf("Failed to create the TIFF page dir, rendering the text: %v", err)
+		return paths, cleanup
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+	pagePaths, err := tiff.Split(opts.ImagePath, dir, "page")
+	if err != nil {
+		log.Printf("Failed to split the TIFF pages, rendering their text: %v", err)
+		return paths, cleanup
+	}
+	for p, page := range opts.Document.Pages {
+		if page.Number >= 1 && page.Number <= len(pagePaths) {
+			paths[p] = pagePaths[page.Number-1]
+		}
+	}
+	return paths, cleanup
+}
+
+// registerPageImage loads the page image in colour, turned as OCR turned
+// it, and adds it to the PDF. The page size defaults to the image size.
+func registerPageImage(pdf *gofpdf.Fpdf, imagePath string, page *models.Page) (string, error) {
+	img, err := preprocess.LoadColor(imagePath)
+	if err != nil {
+		return "", err
+	}
+	img = preprocess.RotateColor(img, page.Orientation)
+	if page.Width == 0 || page.Height == 0 {
+		page.Width, page.Height = img.Rect.Dx(), img.Rect.Dy()
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		return "", fmt.Errorf("failed to encode page image: %w", err)
+	}
+	name := fmt.Sprintf("page-%d", page.Number)
+	pdf.RegisterImageOptionsReader(name, gofpdf.ImageOptions{ImageType: "JPEG"}, &buf)
+	if err := pdf.Error(); err != nil {
+		return "", fmt.Errorf("failed to add page image: %w", err)
+	}
+	return name, nil
+}
+
+// writeWordLayer places every word at its box, sized to its width, on the
+// baseline near the bottom of the box
+func writeWordLayer(pdf *gofpdf.Fpdf, page *models.Page, scale float64) {
+	for _, block := range page.Blocks {
+		for _, line := range block.Lines {
+			for _, word := range line.Words {
+				if word.BBox.Empty() {
+					continue
+				}
+				width := float64(word.BBox.Width()) * scale
+				height := float64(word.BBox.Height()) * scale
+				fitFontSize(pdf, word.Text, width, height)
+				pdf.Text(float64(word.BBox.X0)*scale, float64(word.BBox.Y1)*scale-height*0.2, word.Text)
+			}
+		}
+	}
+}
+
+// writeBlockLayer places the translation of every paragraph in its box, at
+// the size of its original lines; translations longer than the original
+// run past the box. The cells of a table are placed one by one.
+func writeBlockLayer(pdf *gofpdf.Fpdf, page *models.Page, scale float64) {
+	for _, block := range page.Blocks {
+		if block.Table != nil {
+			writeCellLayer(pdf, block.Table, scale)
+			continue
+		}
+		if block.TranslatedText == "" || block.BBox.Empty() || len(block.Lines) == 0 {
+			continue
+		}
+		lineHeight := float64(block.BBox.Height()) * scale / float64(len(block.Lines))
+		pdf.SetFontSize(max(lineHeight*ptPerMM*0.8, 1))
+		pdf.SetXY(float64(block.BBox.X0)*scale, float64(block.BBox.Y0)*scale)
+		pdf.MultiCell(float64(block.BBox.Width())*scale, lineHeight, block.TranslatedText, "", "L", false)
+	}
+}
+
+// writeCellLayer places the translation of every cell in its box, sized to
+// fit its width
+func writeCellLayer(pdf *gofpdf.Fpdf, table *models.Table, scale float64) {
+	for _, row := range table.Rows {
+		for _, cell := range row {
+			if cell.TranslatedText == "" || cell.BBox.Empty() {
+				continue
+			}
+			width := float64(cell.BBox.Width()) * scale
+			height := float64(cell.BBox.Height()) * scale
+			fitFontSize(pdf, cell.TranslatedText, width, min(height, 6))
+			pdf.SetXY(float64(cell.BBox.X0)*scale, float64(cell.BBox.Y0)*scale)
+			pdf.MultiCell(width, height, cell.TranslatedText, "", "L", false)
+		}
+	}
+}
+
+// fitFontSize sets the font size at which text is width wide, starting from
+// the box height
+func fitFontSize(pdf *gofpdf.Fpdf, text string, width, height float64) {
+	size := max(height*ptPerMM, 1)
+	pdf.SetFontSize(size)
+	if textWidth := pdf.GetStringWidth(text); textWidth > 0 {
+		pdf.SetFontSize(max(size*width/textWidth, 1))
+	}
+}
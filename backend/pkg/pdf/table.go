@@ -0,0 +1,64 @@
+package pdf
+
+import (
+	"backend/models"
+
+	gofpdf "github.com/jung-kurt/gofpdf"
+)
+
+// tableFontSize and tableLineHeight set the text of table cells, smaller
+// than the paragraphs so that the columns fit the page
+const (
+	tableFontSize   = 10.0
+	tableLineHeight = 5.0
+)
+
+// writeTable draws the translated table as a grid from the current
+// position to width, the columns as wide, relative to each other, as in the
+// page image. A row that does not fit the page starts a new one.
+func writeTable(pdf *gofpdf.Fpdf, table *models.Table, x, width float64) {
+	if len(table.Rows) == 0 || len(table.Rows[0]) == 0 {
+		return
+	}
+	fontSize, _ := pdf.GetFontSize()
+	pdf.SetFontSize(tableFontSize)
+	defer pdf.SetFontSize(fontSize)
+
+	total := 0
+	for _, cell := range table.Rows[0] {
+		total += max(cell.BBox.Width(), 1)
+	}
+	widths := make([]float64, len(table.Rows[0]))
+	for c, cell := range table.Rows[0] {
+		widths[c] = width * float64(max(cell.BBox.Width(), 1)) / float64(total)
+	}
+
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	autoBreak, breakMargin := pdf.GetAutoPageBreak()
+	// the cells of a row are placed by hand, they must not break apart
+	pdf.SetAutoPageBreak(false, breakMargin)
+	defer pdf.SetAutoPageBreak(autoBreak, breakMargin)
+
+	for _, row := range table.Rows {
+		lines := 1
+		for c, cell := range row {
+			lines = max(lines, len(pdf.SplitText(cell.TranslatedText, widths[c]-2*pdf.GetCellMargin())))
+		}
+		height := float64(lines) * tableLineHeight
+		y := pdf.GetY()
+		if y+height > pageHeight-max(bottom, breakMargin) {
+			pdf.AddPage()
+			y = pdf.GetY()
+		}
+
+		left := x
+		for c, cell := range row {
+			pdf.Rect(left, y, widths[c], height, "D")
+			pdf.SetXY(left, y)
+			pdf.MultiCell(widths[c], tableLineHeight, cell.TranslatedText, "", "L", false)
+			left += widths[c]
+		}
+		pdf.SetXY(x, y+height)
+	}
+}
@@ -0,0 +1,139 @@
+package preprocess
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+// exifOrientationTag is the TIFF tag holding how the camera was held
+const exifOrientationTag = 0x0112
+
+// exifOrientation returns the EXIF orientation (1 to 8) of JPEG data, 1 when
+// there is none
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xD8 || marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			i += 2
+			continue
+		}
+		// the image data follows the start of scan, there is no EXIF after it
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return 1
+		}
+		segment := data[i+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		i = end
+	}
+	return 1
+}
+
+// tiffOrientation reads the orientation tag from the first IFD of a TIFF
+// header, the layout EXIF uses
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[offset:]))
+	for e := 0; e < entries; e++ {
+		entry := offset + 2 + 12*e
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == exifOrientationTag {
+			// a SHORT, stored in the first bytes of the value field
+			value := int(order.Uint16(tiff[entry+8:]))
+			if value < 1 || value > 8 {
+				return 1
+			}
+			return value
+		}
+	}
+	return 1
+}
+
+// applyOrientation turns img upright for its EXIF orientation
+func applyOrientation(img *image.Gray, orientation int) *image.Gray {
+	return orient(img, orientation, Rotate, mirror)
+}
+
+// applyOrientationColor turns img upright for its EXIF orientation
+func applyOrientationColor(img *image.RGBA, orientation int) *image.RGBA {
+	return orient(img, orientation, RotateColor, mirrorColor)
+}
+
+// orient turns img upright for its EXIF orientation: 2, 4, 5 and 7 are
+// mirrored, 3 and 4 turned by 180 degrees, 5 and 8 by 270 and 6 and 7 by
+// 90, clockwise
+func orient[T any](img T, orientation int, rotate func(T, int) T, mirror func(T) T) T {
+	switch orientation {
+	case 2:
+		return mirror(img)
+	case 3:
+		return rotate(img, 180)
+	case 4:
+		return rotate(mirror(img), 180)
+	case 5:
+		return rotate(mirror(img), 270)
+	case 6:
+		return rotate(img, 90)
+	case 7:
+		return rotate(mirror(img), 90)
+	case 8:
+		return rotate(img, 270)
+	default:
+		return img
+	}
+}
+
+// mirror flips img horizontally
+func mirror(img *image.Gray) *image.Gray {
+	out := image.NewGray(image.Rect(0, 0, img.Rect.Dx(), img.Rect.Dy()))
+	flip(out.Pix, out.Stride, img.Pix, img.Stride, img.Rect.Dx(), img.Rect.Dy(), 1)
+	return out
+}
+
+// mirrorColor flips img horizontally
+func mirrorColor(img *image.RGBA) *image.RGBA {
+	out := image.NewRGBA(image.Rect(0, 0, img.Rect.Dx(), img.Rect.Dy()))
+	flip(out.Pix, out.Stride, img.Pix, img.Stride, img.Rect.Dx(), img.Rect.Dy(), 4)
+	return out
+}
+
+// flip copies the pixels of a width x height image of bpp bytes per pixel
+// from src to dst, flipped horizontally
+func flip(dst []uint8, dstStride int, src []uint8, srcStride, width, height, bpp int) {
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			copy(dst[y*dstStride+x*bpp:y*dstStride+(x+1)*bpp], src[y*srcStride+(width-1-x)*bpp:])
+		}
+	}
+}
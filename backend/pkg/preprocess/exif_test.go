@@ -0,0 +1,143 @@
+package preprocess
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"io"
+	"testing"
+)
+
+// exifSegment returns a JPEG APP1 segment whose EXIF holds the orientation,
+// in the byte order marked by mark
+func exifSegment(orientation uint16, order binary.AppendByteOrder, mark string) []byte {
+	tiff := []byte(mark)
+	tiff = order.AppendUint16(tiff, 42)
+	tiff = order.AppendUint32(tiff, 8)
+	tiff = order.AppendUint16(tiff, 1)
+	tiff = order.AppendUint16(tiff, exifOrientationTag)
+	tiff = order.AppendUint16(tiff, 3)
+	tiff = order.AppendUint32(tiff, 1)
+	tiff = order.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := binary.BigEndian.AppendUint16([]byte{0xFF, 0xE1}, uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// jpegWithOrientation returns the start of a JPEG file, an APP0 segment then
+// the EXIF one
+func jpegWithOrientation(orientation uint16, order binary.AppendByteOrder, mark string) []byte {
+	data := []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x04, 0x00, 0x00}
+	data = append(data, exifSegment(orientation, order, mark)...)
+	return append(data, 0xFF, 0xDA, 0x00, 0x02)
+}
+
+func TestExifOrientation(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"little endian", jpegWithOrientation(6, binary.LittleEndian, "II"), 6},
+		{"big endian", jpegWithOrientation(8, binary.BigEndian, "MM"), 8},
+		{"out of range", jpegWithOrientation(9, binary.LittleEndian, "II"), 1},
+		{"bad byte order", jpegWithOrientation(6, binary.LittleEndian, "XX"), 1},
+		{"no exif", []byte{0xFF, 0xD8, 0xFF, 0xDA, 0x00, 0x02}, 1},
+		{"not a jpeg", []byte("\x89PNG\r\n\x1a\n"), 1},
+		{"truncated", jpegWithOrientation(6, binary.LittleEndian, "II")[:20], 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exifOrientation(tt.data); got != tt.want {
+				t.Errorf("exifOrientation() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// 1 2 3
+	// 4 5 6
+	img := grayFrom([][]uint8{{1, 2, 3}, {4, 5, 6}})
+	tests := []struct {
+		orientation int
+		want        [][]uint8
+	}{
+		{1, [][]uint8{{1, 2, 3}, {4, 5, 6}}},
+		{2, [][]uint8{{3, 2, 1}, {6, 5, 4}}},
+		{3, [][]uint8{{6, 5, 4}, {3, 2, 1}}},
+		{4, [][]uint8{{4, 5, 6}, {1, 2, 3}}},
+		{5, [][]uint8{{1, 4}, {2, 5}, {3, 6}}},
+		{6, [][]uint8{{4, 1}, {5, 2}, {6, 3}}},
+		{7, [][]uint8{{6, 3}, {5, 2}, {4, 1}}},
+		{8, [][]uint8{{3, 6}, {2, 5}, {1, 4}}},
+	}
+	for _, tt := range tests {
+		got := applyOrientation(img, tt.orientation)
+		if want := grayFrom(tt.want); got.Rect != want.Rect || string(got.Pix) != string(want.Pix) {
+			t.Errorf("applyOrientation(%d) = %v %v, want %v %v", tt.orientation, got.Rect, got.Pix, want.Rect, want.Pix)
+		}
+	}
+}
+
+func TestLoadOriented(t *testing.T) {
+	// black on the left half, white on the right, with EXIF orientation 6:
+	// shown turned a quarter clockwise
+	img := image.NewGray(image.Rect(0, 0, 32, 16))
+	for y := 0; y < 16; y++ {
+		for x := 16; x < 32; x++ {
+			img.Pix[y*img.Stride+x] = 255
+		}
+	}
+	path := writeImage(t, "photo.jpg", func(w io.Writer) error {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}); err != nil {
+			return err
+		}
+		// the EXIF segment goes right after the start of image
+		data := buf.Bytes()
+		data = append(append(data[:2:2], exifSegment(6, binary.BigEndian, "MM")...), data[2:]...)
+		_, err := w.Write(data)
+		return err
+	})
+
+	if !Oriented(path) {
+		t.Fatal("Oriented() = false for orientation 6")
+	}
+	gray, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	colored, err := LoadColor(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// turned clockwise upright, the left half is on top
+	for _, bounds := range []image.Rectangle{gray.Rect, colored.Rect} {
+		if bounds != image.Rect(0, 0, 16, 32) {
+			t.Fatalf("loaded bounds = %v, want %v", bounds, image.Rect(0, 0, 16, 32))
+		}
+	}
+	for _, p := range []struct {
+		x, y int
+		want uint8
+	}{{8, 4, 0}, {8, 12, 0}, {8, 20, 255}, {8, 28, 255}} {
+		// JPEG is lossy, a few levels either way are fine
+		if got := gray.GrayAt(p.x, p.y).Y; absDiff(got, p.want) > 4 {
+			t.Errorf("Load() at (%d, %d) = %d, want %d", p.x, p.y, got, p.want)
+		}
+		if got := colored.RGBAAt(p.x, p.y).G; absDiff(got, p.want) > 4 {
+			t.Errorf("LoadColor() at (%d, %d) = %d, want %d", p.x, p.y, got, p.want)
+		}
+	}
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
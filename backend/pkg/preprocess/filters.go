@@ -0,0 +1,153 @@
+package preprocess
+
+import (
+	"image"
+)
+
+// otsuThreshold returns the gray level that best separates ink from paper,
+// maximizing the variance between the two classes
+func otsuThreshold(img *image.Gray) uint8 {
+	var histogram [256]int
+	for _, v := range img.Pix {
+		histogram[v]++
+	}
+
+	total := len(img.Pix)
+	var sum float64
+	for level, count := range histogram {
+		sum += float64(level * count)
+	}
+
+	var sumBackground, best float64
+	weightBackground := 0
+	threshold := 0
+	for level, count := range histogram {
+		weightBackground += count
+		weightForeground := total - weightBackground
+		if weightBackground == 0 {
+			continue
+		}
+		if weightForeground == 0 {
+			break
+		}
+		sumBackground += float64(level * count)
+		meanBackground := sumBackground / float64(weightBackground)
+		meanForeground := (sum - sumBackground) / float64(weightForeground)
+		between := float64(weightBackground) * float64(weightForeground) * (meanBackground - meanForeground) * (meanBackground - meanForeground)
+		if between > best {
+			best = between
+			threshold = level
+		}
+	}
+	return uint8(threshold)
+}
+
+func binarizeOtsu(img *image.Gray) *image.Gray {
+	threshold := otsuThreshold(img)
+	out := image.NewGray(img.Bounds())
+	for i, v := range img.Pix {
+		if v > threshold {
+			out.Pix[i] = 255
+		}
+	}
+	return out
+}
+
+// adaptiveBias is how much darker than the mean of its neighbourhood a pixel
+// must be to count as ink, in percent
+const adaptiveBias = 15
+
+// binarizeAdaptive compares each pixel with the mean of a window around it
+// (Bradley's method), computed from an integral image
+func binarizeAdaptive(img *image.Gray) *image.Gray {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	radius := max(width/80, 7)
+
+	integral := make([]int64, (width+1)*(height+1))
+	for y := 0; y < height; y++ {
+		var row int64
+		for x := 0; x < width; x++ {
+			row += int64(img.Pix[y*img.Stride+x])
+			integral[(y+1)*(width+1)+x+1] = integral[y*(width+1)+x+1] + row
+		}
+	}
+
+	out := image.NewGray(bounds)
+	for y := 0; y < height; y++ {
+		y0, y1 := max(y-radius, 0), min(y+radius+1, height)
+		for x := 0; x < width; x++ {
+			x0, x1 := max(x-radius, 0), min(x+radius+1, width)
+			sum := integral[y1*(width+1)+x1] - integral[y0*(width+1)+x1] - integral[y1*(width+1)+x0] + integral[y0*(width+1)+x0]
+			count := int64((x1 - x0) * (y1 - y0))
+			if int64(img.Pix[y*img.Stride+x])*count*100 > sum*(100-adaptiveBias) {
+				out.Pix[y*out.Stride+x] = 255
+			}
+		}
+	}
+	return out
+}
+
+// denoise applies a 3x3 median filter, which removes speckles and fax noise
+// while keeping the edges of the characters
+func denoise(img *image.Gray) *image.Gray {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	out := image.NewGray(bounds)
+	var window [9]uint8
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			n := 0
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					sx := min(max(x+dx, 0), width-1)
+					sy := min(max(y+dy, 0), height-1)
+					window[n] = img.Pix[sy*img.Stride+sx]
+					n++
+				}
+			}
+			// insertion sort, the window is tiny
+			for i := 1; i < len(window); i++ {
+				for j := i; j > 0 && window[j] < window[j-1]; j-- {
+					window[j], window[j-1] = window[j-1], window[j]
+				}
+			}
+			out.Pix[y*out.Stride+x] = window[4]
+		}
+	}
+	return out
+}
+
+// contrastClip is the share of the darkest and lightest pixels, in percent,
+// ignored when stretching the levels so that a few outliers do not count
+const contrastClip = 1
+
+// normalizeContrast stretches the gray levels to the full range
+func normalizeContrast(img *image.Gray) *image.Gray {
+	var histogram [256]int
+	for _, v := range img.Pix {
+		histogram[v]++
+	}
+	clip := len(img.Pix) * contrastClip / 100
+	low, high := 0, 255
+	for count := 0; low < 255 && count+histogram[low] <= clip; low++ {
+		count += histogram[low]
+	}
+	for count := 0; high > 0 && count+histogram[high] <= clip; high-- {
+		count += histogram[high]
+	}
+
+	out := image.NewGray(img.Bounds())
+	if high <= low {
+		copy(out.Pix, img.Pix)
+		return out
+	}
+	var levels [256]uint8
+	for v := range levels {
+		levels[v] = uint8(min(max((v-low)*255/(high-low), 0), 255))
+	}
+	for i, v := range img.Pix {
+		out.Pix[i] = levels[v]
+	}
+	return out
+}
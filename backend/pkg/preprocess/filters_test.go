@@ -0,0 +1,74 @@
+package preprocess
+
+import (
+	"image"
+	"testing"
+)
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter func(*image.Gray) *image.Gray
+		in     [][]uint8
+		want   [][]uint8
+	}{
+		{
+			name:   "otsu",
+			filter: binarizeOtsu,
+			in:     [][]uint8{{20, 30, 200}, {220, 25, 210}},
+			want:   [][]uint8{{0, 0, 255}, {255, 0, 255}},
+		},
+		{
+			name:   "median removes a speckle",
+			filter: denoise,
+			in:     [][]uint8{{255, 255, 255}, {255, 0, 255}, {255, 255, 255}},
+			want:   [][]uint8{{255, 255, 255}, {255, 255, 255}, {255, 255, 255}},
+		},
+		{
+			name:   "contrast stretched",
+			filter: normalizeContrast,
+			in:     [][]uint8{{100, 150}, {150, 200}},
+			want:   [][]uint8{{0, 127}, {127, 255}},
+		},
+		{
+			name:   "flat contrast kept",
+			filter: normalizeContrast,
+			in:     [][]uint8{{90, 90}, {90, 90}},
+			want:   [][]uint8{{90, 90}, {90, 90}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.filter(grayFrom(tt.in))
+			if want := grayFrom(tt.want); string(got.Pix) != string(want.Pix) {
+				t.Errorf("got %v, want %v", got.Pix, want.Pix)
+			}
+		})
+	}
+}
+
+func TestBinarizeAdaptive(t *testing.T) {
+	// a dark stroke on a page darkening from left to right, where a single
+	// threshold would lose either the stroke or the right side of the page
+	img := image.NewGray(image.Rect(0, 0, 60, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 60; x++ {
+			img.Pix[y*img.Stride+x] = uint8(240 - 2*x)
+		}
+	}
+	for y := 8; y < 12; y++ {
+		for x := 40; x < 44; x++ {
+			img.Pix[y*img.Stride+x] = 40
+		}
+	}
+
+	out := binarizeAdaptive(img)
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 60; x++ {
+			stroke := y >= 8 && y < 12 && x >= 40 && x < 44
+			if ink := out.Pix[y*out.Stride+x] == 0; ink != stroke {
+				t.Fatalf("pixel (%d, %d) ink = %v, want %v", x, y, ink, stroke)
+			}
+		}
+	}
+}
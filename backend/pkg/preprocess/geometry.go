@@ -0,0 +1,206 @@
+package preprocess
+
+import (
+	"image"
+	"math"
+)
+
+const (
+	// upscaleTarget is the long side, in pixels, low resolution images are
+	// scaled up to; about an A4 page at 200 DPI
+	upscaleTarget = 2400
+	maxUpscale    = 4
+)
+
+// Geometry maps points of a preprocessed image back to the image the steps
+// ran on, upscale scales the pixels and deskew turns them about the center.
+// The zero value maps every point to itself.
+type Geometry struct {
+	moved bool
+	// x' = m[0]*x + m[1]*y + m[2], y' = m[3]*x + m[4]*y + m[5]
+	m [6]float64
+}
+
+// Moved reports whether the steps moved any pixel
+func (g Geometry) Moved() bool {
+	return g.moved
+}
+
+// then is g after a step that moved the pixels as step says
+func (g Geometry) then(step Geometry) Geometry {
+	if !g.moved {
+		return step
+	}
+	if !step.moved {
+		return g
+	}
+	a, m := g.m, step.m
+	return Geometry{moved: true, m: [6]float64{
+		a[0]*m[0] + a[1]*m[3], a[0]*m[1] + a[1]*m[4], a[0]*m[2] + a[1]*m[5] + a[2],
+		a[3]*m[0] + a[4]*m[3], a[3]*m[1] + a[4]*m[4], a[3]*m[2] + a[4]*m[5] + a[5],
+	}}
+}
+
+// Unmap returns the point of the original image shown at x, y of the
+// preprocessed one
+func (g Geometry) Unmap(x, y float64) (float64, float64) {
+	if !g.moved {
+		return x, y
+	}
+	m := g.m
+	return m[0]*x + m[1]*y + m[2], m[3]*x + m[4]*y + m[5]
+}
+
+// UnmapRect returns where r of the preprocessed image lies in the original
+// one. Its center is mapped back and its sides scaled, a deskewed box keeps
+// its size rather than growing to hold the turned one.
+func (g Geometry) UnmapRect(r image.Rectangle) image.Rectangle {
+	if !g.moved || r.Empty() {
+		return r
+	}
+	cx, cy := g.Unmap(float64(r.Min.X+r.Max.X)/2, float64(r.Min.Y+r.Max.Y)/2)
+	halfWidth := float64(r.Dx()) * math.Hypot(g.m[0], g.m[3]) / 2
+	halfHeight := float64(r.Dy()) * math.Hypot(g.m[1], g.m[4]) / 2
+	return image.Rect(
+		int(math.Round(cx-halfWidth)), int(math.Round(cy-halfHeight)),
+		int(math.Round(cx+halfWidth)), int(math.Round(cy+halfHeight)),
+	)
+}
+
+// upscale enlarges small images, Tesseract reads characters best when they
+// are 20 to 30 pixels tall
+func upscale(img *image.Gray) (*image.Gray, Geometry) {
+	bounds := img.Bounds()
+	long := max(bounds.Dx(), bounds.Dy())
+	if long == 0 {
+		return img, Geometry{}
+	}
+	scale := min(float64(upscaleTarget)/float64(long), maxUpscale)
+	if scale < 1.2 {
+		return img, Geometry{}
+	}
+
+	width, height := int(float64(bounds.Dx())*scale), int(float64(bounds.Dy())*scale)
+	out := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			out.Pix[y*out.Stride+x] = bilinear(img, (float64(x)+0.5)/scale-0.5, (float64(y)+0.5)/scale-0.5)
+		}
+	}
+	// the sizes are rounded down, each side is scaled back by its own ratio
+	scaleX, scaleY := float64(bounds.Dx())/float64(width), float64(bounds.Dy())/float64(height)
+	return out, Geometry{moved: true, m: [6]float64{scaleX, 0, 0, 0, scaleY, 0}}
+}
+
+// bilinear samples img at a fractional position, outside is white
+func bilinear(img *image.Gray, x, y float64) uint8 {
+	bounds := img.Bounds()
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+	at := func(px, py int) float64 {
+		if px < 0 || py < 0 || px >= bounds.Dx() || py >= bounds.Dy() {
+			return 255
+		}
+		return float64(img.Pix[py*img.Stride+px])
+	}
+	top := at(x0, y0)*(1-fx) + at(x0+1, y0)*fx
+	bottom := at(x0, y0+1)*(1-fx) + at(x0+1, y0+1)*fx
+	return uint8(math.Round(top*(1-fy) + bottom*fy))
+}
+
+const (
+	// maxSkew is the largest skew deskew corrects, in degrees
+	maxSkew = 5
+	// skewSamples bounds the ink pixels projected for each angle
+	skewSamples = 20000
+)
+
+// deskew finds the rotation that makes the text lines horizontal, the one
+// whose horizontal projection profile has the sharpest transitions between
+// lines and gaps, and rotates the image by it
+func deskew(img *image.Gray) (*image.Gray, Geometry) {
+	angle := skewAngle(img)
+	if math.Abs(angle) < 0.1 {
+		return img, Geometry{}
+	}
+	return rotate(img, angle), rotation(img.Bounds(), angle)
+}
+
+func skewAngle(img *image.Gray) float64 {
+	bounds := img.Bounds()
+	threshold := otsuThreshold(img)
+	var points []image.Point
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			if img.Pix[y*img.Stride+x] <= threshold {
+				points = append(points, image.Point{X: x, Y: y})
+			}
+		}
+	}
+	if len(points) == 0 {
+		return 0
+	}
+	if len(points) > skewSamples {
+		step := len(points) / skewSamples
+		sampled := make([]image.Point, 0, skewSamples+1)
+		for i := 0; i < len(points); i += step {
+			sampled = append(sampled, points[i])
+		}
+		points = sampled
+	}
+
+	offset := bounds.Dx() + bounds.Dy()
+	rows := make([]int, 2*offset+1)
+	score := func(angle float64) float64 {
+		clear(rows)
+		sin, cos := math.Sincos(angle * math.Pi / 180)
+		for _, p := range points {
+			rows[int(float64(p.X)*sin+float64(p.Y)*cos)+offset]++
+		}
+		var sum float64
+		for i := 1; i < len(rows); i++ {
+			d := float64(rows[i] - rows[i-1])
+			sum += d * d
+		}
+		return sum
+	}
+
+	// coarse search, then refine around the best angle
+	best, bestScore := 0.0, score(0)
+	search := func(from, to, step float64) {
+		for angle := from; angle <= to+1e-9; angle += step {
+			if s := score(angle); s > bestScore {
+				best, bestScore = angle, s
+			}
+		}
+	}
+	search(-maxSkew, maxSkew, 0.5)
+	search(best-0.5, best+0.5, 0.1)
+	return best
+}
+
+// rotation is the Geometry of rotate
+func rotation(bounds image.Rectangle, angle float64) Geometry {
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	cx, cy := float64(bounds.Dx())/2, float64(bounds.Dy())/2
+	return Geometry{moved: true, m: [6]float64{
+		cos, sin, cx - cx*cos - cy*sin,
+		-sin, cos, cy + cx*sin - cy*cos,
+	}}
+}
+
+// rotate turns img by angle degrees around its center, keeping its size,
+// with white corners
+func rotate(img *image.Gray, angle float64) *image.Gray {
+	bounds := img.Bounds()
+	out := image.NewGray(bounds)
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	cx, cy := float64(bounds.Dx())/2, float64(bounds.Dy())/2
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			dx, dy := float64(x)-cx, float64(y)-cy
+			out.Pix[y*out.Stride+x] = bilinear(img, dx*cos+dy*sin+cx, -dx*sin+dy*cos+cy)
+		}
+	}
+	return out
+}
@@ -0,0 +1,142 @@
+package preprocess
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+// textPage draws dark horizontal bars, lines of text, on a white page
+func textPage(width, height int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	for y := 20; y+8 < height-20; y += 24 {
+		for dy := 0; dy < 8; dy++ {
+			for x := 20; x < width-20; x++ {
+				img.Pix[(y+dy)*img.Stride+x] = 0
+			}
+		}
+	}
+	return img
+}
+
+func TestDeskew(t *testing.T) {
+	page := textPage(300, 300)
+	for _, angle := range []float64{-3, -1.5, 2, 4} {
+		skewed := rotate(page, angle)
+		if got := skewAngle(skewed); math.Abs(got+angle) > 0.3 {
+			t.Errorf("skewAngle() of a page turned by %.1f = %.1f, want %.1f", angle, got, -angle)
+		}
+		deskewed, geometry := deskew(skewed)
+		if got := skewAngle(deskewed); math.Abs(got) > 0.3 {
+			t.Errorf("skewAngle() of the deskewed page turned by %.1f = %.1f, want 0", angle, got)
+		}
+		if !geometry.Moved() {
+			t.Errorf("deskew() of a page turned by %.1f returned no geometry", angle)
+		}
+	}
+	if got, geometry := deskew(page); got != page || geometry.Moved() {
+		t.Error("deskew() changed a straight page")
+	}
+}
+
+func TestUpscale(t *testing.T) {
+	tests := []struct {
+		name                  string
+		width, height         int
+		wantWidth, wantHeight int
+	}{
+		{"small", 300, 200, 1200, 800},
+		{"medium", 1200, 600, 2400, 1200},
+		{"large enough", 2200, 1000, 2200, 1000},
+		{"empty", 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, geometry := upscale(image.NewGray(image.Rect(0, 0, tt.width, tt.height)))
+			got := img.Bounds()
+			if got.Dx() != tt.wantWidth || got.Dy() != tt.wantHeight {
+				t.Errorf("upscale(%dx%d) = %dx%d, want %dx%d", tt.width, tt.height, got.Dx(), got.Dy(), tt.wantWidth, tt.wantHeight)
+			}
+			if back := geometry.UnmapRect(got); back != image.Rect(0, 0, tt.width, tt.height) {
+				t.Errorf("UnmapRect() of the upscaled image = %v, want %v", back, image.Rect(0, 0, tt.width, tt.height))
+			}
+		})
+	}
+}
+
+func TestUpscaleInterpolates(t *testing.T) {
+	// black on the left half, white on the right, scaled by 4
+	img := image.NewGray(image.Rect(0, 0, 300, 200))
+	for y := 0; y < 200; y++ {
+		for x := 150; x < 300; x++ {
+			img.Pix[y*img.Stride+x] = 255
+		}
+	}
+	out, _ := upscale(img)
+
+	// the edge between the halves is a ramp over the pixels around x = 600,
+	// the image border is blended with the white outside
+	tests := []struct {
+		x    int
+		want uint8
+	}{
+		{0, 96}, {2, 0}, {300, 0},
+		{597, 0}, {598, 32}, {599, 96}, {600, 159}, {601, 223}, {602, 255},
+		{900, 255}, {1199, 255},
+	}
+	for _, tt := range tests {
+		if got := out.GrayAt(tt.x, 400).Y; got != tt.want {
+			t.Errorf("upscaled pixel at x = %d is %d, want %d", tt.x, got, tt.want)
+		}
+	}
+}
+
+func TestGeometry(t *testing.T) {
+	_, scaled := upscale(image.NewGray(image.Rect(0, 0, 300, 200)))
+	turned := rotation(image.Rect(0, 0, 1200, 800), 2)
+	geometry := Geometry{}.then(scaled).then(turned)
+
+	// upscaled, then deskewed: a point is turned back, then scaled back
+	for _, p := range [][2]float64{{0, 0}, {600, 400}, {1000, 100}} {
+		wantX, wantY := scaled.Unmap(turned.Unmap(p[0], p[1]))
+		if x, y := geometry.Unmap(p[0], p[1]); math.Abs(x-wantX) > 1e-9 || math.Abs(y-wantY) > 1e-9 {
+			t.Errorf("Unmap(%v) = %.3f, %.3f, want %.3f, %.3f", p, x, y, wantX, wantY)
+		}
+	}
+
+	// the center of the image stays put, a box around it is scaled back
+	if got, want := geometry.UnmapRect(image.Rect(500, 380, 700, 420)), image.Rect(125, 95, 175, 105); got != want {
+		t.Errorf("UnmapRect() = %v, want %v", got, want)
+	}
+	if got := (Geometry{}).UnmapRect(image.Rect(1, 2, 3, 4)); got != image.Rect(1, 2, 3, 4) {
+		t.Errorf("UnmapRect() of the zero Geometry = %v, want the rectangle itself", got)
+	}
+}
+
+func TestApplyGeometry(t *testing.T) {
+	page := textPage(300, 300)
+	skewed := rotate(page, 3)
+	out, geometry := ApplyGeometry(skewed, []string{StepGrayscale, StepUpscale, StepDeskew}, nil)
+	if out.Rect != image.Rect(0, 0, 1200, 1200) || !geometry.Moved() {
+		t.Fatalf("ApplyGeometry() = %v, moved %v, want upscaled and deskewed", out.Rect, geometry.Moved())
+	}
+
+	// the lines of the result are the lines of the skewed page: ink at the
+	// middle of the first line, paper in the gap below it
+	for _, p := range []struct {
+		x, y float64
+		ink  bool
+	}{{600, 96, true}, {300, 96, true}, {900, 96, true}, {600, 160, false}} {
+		x, y := geometry.Unmap(p.x, p.y)
+		if ink := skewed.GrayAt(int(x), int(y)).Y < 128; ink != p.ink {
+			t.Errorf("Unmap(%v, %v) = %.1f, %.1f, ink %v, want %v", p.x, p.y, x, y, ink, p.ink)
+		}
+	}
+
+	if _, geometry := ApplyGeometry(skewed, []string{StepGrayscale, StepBinarize}, nil); geometry.Moved() {
+		t.Error("ApplyGeometry() of filters that do not move pixels returned a geometry")
+	}
+}
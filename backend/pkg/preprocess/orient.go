@@ -0,0 +1,103 @@
+package preprocess
+
+import (
+	"image"
+)
+
+// Rotate turns img clockwise by degrees, a multiple of 90
+func Rotate(img *image.Gray, degrees int) *image.Gray {
+	degrees = ((degrees % 360) + 360) % 360
+	if degrees == 0 {
+		return img
+	}
+	out := image.NewGray(turnedRect(img.Rect, degrees))
+	turn(out.Pix, out.Stride, img.Pix, img.Stride, img.Rect.Dx(), img.Rect.Dy(), 1, degrees)
+	return out
+}
+
+// RotateColor turns img clockwise by degrees, a multiple of 90, as Rotate
+// does for grayscale images
+func RotateColor(img *image.RGBA, degrees int) *image.RGBA {
+	degrees = ((degrees % 360) + 360) % 360
+	if degrees == 0 {
+		return img
+	}
+	out := image.NewRGBA(turnedRect(img.Rect, degrees))
+	turn(out.Pix, out.Stride, img.Pix, img.Stride, img.Rect.Dx(), img.Rect.Dy(), 4, degrees)
+	return out
+}
+
+// turnedRect is the bounds of an image of the size of bounds turned by
+// degrees
+func turnedRect(bounds image.Rectangle, degrees int) image.Rectangle {
+	if degrees == 180 {
+		return image.Rect(0, 0, bounds.Dx(), bounds.Dy())
+	}
+	return image.Rect(0, 0, bounds.Dy(), bounds.Dx())
+}
+
+// turn copies the pixels of a width x height image of bpp bytes per pixel
+// from src to dst, turned clockwise by 90, 180 or 270 degrees
+func turn(dst []uint8, dstStride int, src []uint8, srcStride, width, height, bpp, degrees int) {
+	outWidth, outHeight := height, width
+	if degrees == 180 {
+		outWidth, outHeight = width, height
+	}
+	for y := 0; y < outHeight; y++ {
+		for x := 0; x < outWidth; x++ {
+			var sx, sy int
+			switch degrees {
+			case 90:
+				sx, sy = y, height-1-x
+			case 180:
+				sx, sy = width-1-x, height-1-y
+			default:
+				sx, sy = width-1-y, x
+			}
+			copy(dst[y*dstStride+x*bpp:y*dstStride+(x+1)*bpp], src[sy*srcStride+sx*bpp:])
+		}
+	}
+}
+
+// TextVertical reports whether the lines of text of img run from top to
+// bottom, a page turned by 90 or 270 degrees. Horizontal lines make the ink
+// per row alternate between lines and gaps while every column crosses all
+// lines and evens out, so the rows vary more than the columns; turned pages
+// are the other way around.
+func TextVertical(img *image.Gray) bool {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	threshold := otsuThreshold(img)
+	rows := make([]float64, height)
+	columns := make([]float64, width)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if img.Pix[y*img.Stride+x] <= threshold {
+				rows[y]++
+				columns[x]++
+			}
+		}
+	}
+	return variation(columns) > variation(rows)
+}
+
+// variation is the coefficient of variation of the profile, its standard
+// deviation relative to its mean, squared
+func variation(profile []float64) float64 {
+	if len(profile) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range profile {
+		sum += v
+	}
+	mean := sum / float64(len(profile))
+	if mean == 0 {
+		return 0
+	}
+	var squares float64
+	for _, v := range profile {
+		squares += (v - mean) * (v - mean)
+	}
+	return squares / float64(len(profile)) / (mean * mean)
+}
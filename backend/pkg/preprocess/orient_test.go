@@ -0,0 +1,76 @@
+package preprocess
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestRotate(t *testing.T) {
+	img := grayFrom([][]uint8{{1, 2, 3}, {4, 5, 6}})
+	tests := []struct {
+		degrees int
+		want    [][]uint8
+	}{
+		{0, [][]uint8{{1, 2, 3}, {4, 5, 6}}},
+		{90, [][]uint8{{4, 1}, {5, 2}, {6, 3}}},
+		{180, [][]uint8{{6, 5, 4}, {3, 2, 1}}},
+		{270, [][]uint8{{3, 6}, {2, 5}, {1, 4}}},
+		{-90, [][]uint8{{3, 6}, {2, 5}, {1, 4}}},
+		{450, [][]uint8{{4, 1}, {5, 2}, {6, 3}}},
+	}
+	for _, tt := range tests {
+		got := Rotate(img, tt.degrees)
+		if want := grayFrom(tt.want); got.Rect != want.Rect || string(got.Pix) != string(want.Pix) {
+			t.Errorf("Rotate(%d) = %v %v, want %v %v", tt.degrees, got.Rect, got.Pix, want.Rect, want.Pix)
+		}
+	}
+}
+
+func TestTextVertical(t *testing.T) {
+	page := textPage(300, 200)
+	tests := []struct {
+		degrees int
+		want    bool
+	}{
+		{0, false},
+		{90, true},
+		{180, false},
+		{270, true},
+	}
+	for _, tt := range tests {
+		if got := TextVertical(Rotate(page, tt.degrees)); got != tt.want {
+			t.Errorf("TextVertical() of a page turned by %d = %v, want %v", tt.degrees, got, tt.want)
+		}
+	}
+}
+
+func TestRotateColor(t *testing.T) {
+	// red green
+	// blue white
+	red, green, blue, white := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 255, 0, 255}, color.RGBA{0, 0, 255, 255}, color.RGBA{255, 255, 255, 255}
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.SetRGBA(0, 0, red)
+	img.SetRGBA(1, 0, green)
+	img.SetRGBA(0, 1, blue)
+	img.SetRGBA(1, 1, white)
+	tests := []struct {
+		degrees int
+		want    [2][2]color.RGBA
+	}{
+		{0, [2][2]color.RGBA{{red, green}, {blue, white}}},
+		{90, [2][2]color.RGBA{{blue, red}, {white, green}}},
+		{180, [2][2]color.RGBA{{white, blue}, {green, red}}},
+		{270, [2][2]color.RGBA{{green, white}, {red, blue}}},
+	}
+	for _, tt := range tests {
+		got := RotateColor(img, tt.degrees)
+		for y, row := range tt.want {
+			for x, want := range row {
+				if c := got.RGBAAt(x, y); c != want {
+					t.Errorf("RotateColor(%d) at (%d, %d) = %v, want %v", tt.degrees, x, y, c, want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Package preprocess cleans up page images before OCR: orientation,
+// grayscale, binarization, denoising, contrast normalization, upscaling and
+// deskew.
+package preprocess
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/tiff"
+)
+
+const (
+	StepGrayscale = "grayscale"
+	// StepBinarize thresholds the whole page with Otsu's method
+	StepBinarize = "binarize"
+	// StepAdaptive thresholds each pixel against its neighbourhood, for
+	// uneven lighting
+	StepAdaptive = "adaptive"
+	StepDenoise  = "denoise"
+	StepContrast = "contrast"
+	StepUpscale  = "upscale"
+	StepDeskew   = "deskew"
+	// StepOrient turns pages scanned sideways or upside down upright. It
+	// needs Tesseract, so pkg/ocr runs it before the other steps.
+	StepOrient = "orient"
+)
+
+// Presets name step lists for common sources, applied in order
+var Presets = map[string][]string{
+	"none":  nil,
+	"scan":  {StepOrient, StepGrayscale, StepDeskew, StepBinarize},
+	"photo": {StepOrient, StepGrayscale, StepContrast, StepDenoise, StepDeskew, StepAdaptive},
+	"fax":   {StepOrient, StepGrayscale, StepUpscale, StepDenoise, StepDeskew, StepBinarize},
+}
+
+var filters = map[string]func(*image.Gray) *image.Gray{
+	StepGrayscale: func(img *image.Gray) *image.Gray { return img },
+	StepBinarize:  binarizeOtsu,
+	StepAdaptive:  binarizeAdaptive,
+	StepDenoise:   denoise,
+	StepContrast:  normalizeContrast,
+}
+
+// transforms are the steps that move the pixels, see Geometry
+var transforms = map[string]func(*image.Gray) (*image.Gray, Geometry){
+	StepUpscale: upscale,
+	StepDeskew:  deskew,
+}
+
+// Parse reads the preprocess upload parameter, a preset name or a comma
+// separated list of steps. Empty selects the preset in OCR_PREPROCESS.
+func Parse(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		value = strings.TrimSpace(os.Getenv("OCR_PREPROCESS"))
+	}
+	if value == "" {
+		return nil, nil
+	}
+	if steps, ok := Presets[value]; ok {
+		return steps, nil
+	}
+
+	var steps []string
+	for _, step := range strings.Split(value, ",") {
+		step = strings.TrimSpace(step)
+		if step == "" {
+			continue
+		}
+		_, isFilter := filters[step]
+		_, isTransform := transforms[step]
+		if !isFilter && !isTransform && step != StepOrient {
+			return nil, fmt.Errorf("unknown preprocessing step or preset: %s", step)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// Load decodes an image as grayscale, turned as its EXIF orientation says
+func Load(imagePath string) (*image.Gray, error) {
+	img, orientation, err := decode(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	return applyOrientation(toGray(img), orientation), nil
+}
+
+// LoadColor decodes an image in colour, turned as its EXIF orientation
+// says, to show the page rather than to read it
+func LoadColor(imagePath string) (*image.RGBA, error) {
+	img, orientation, err := decode(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	return applyOrientationColor(toRGBA(img), orientation), nil
+}
+
+// decode reads an image and its EXIF orientation. TIFF files decode to
+// their first page, tiff.Split writes the others to files of their own.
+func decode(imagePath string) (image.Image, int, error) {
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open image: %w", err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	var unsupported tiff.UnsupportedError
+	if errors.As(err, &unsupported) {
+		// a TIFF compression the decoder lacks is as unreadable as an
+		// unknown format, callers fall back to Tesseract for both
+		err = fmt.Errorf("%w: %v", image.ErrFormat, err)
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, exifOrientation(data), nil
+}
+
+// Oriented reports whether the EXIF orientation of the image turns it, then
+// Tesseract, which ignores EXIF, must be given the image from Load
+func Oriented(imagePath string) bool {
+	data, err := os.ReadFile(imagePath)
+	return err == nil && exifOrientation(data) > 1
+}
+
+// SaveTemp writes img to a temporary PNG file and returns its path
+func SaveTemp(img *image.Gray) (string, error) {
+	out, err := os.CreateTemp("", "ocr-preprocess-*.png")
+	if err != nil {
+		return "", fmt.Errorf("failed to create preprocessed image: %w", err)
+	}
+	err = png.Encode(out, img)
+	out.Close()
+	if err != nil {
+		os.Remove(out.Name())
+		return "", fmt.Errorf("failed to write preprocessed image: %w", err)
+	}
+	return out.Name(), nil
+}
+
+// DebugSaver returns an Apply callback saving the result of every step to
+// OCR_PREPROCESS_DEBUG_DIR, named after name, or nil when it is not set
+func DebugSaver(name string) func(i int, step string, result *image.Gray) {
+	if os.Getenv("OCR_PREPROCESS_DEBUG_DIR") == "" {
+		return nil
+	}
+	return func(i int, step string, result *image.Gray) {
+		saveDebug(name, i, step, result)
+	}
+}
+
+// Apply runs the steps on img, calling after, if not nil, with the result
+// of each step. StepOrient is skipped.
+func Apply(img *image.Gray, steps []string, after func(i int, step string, result *image.Gray)) *image.Gray {
+	img, _ = ApplyGeometry(img, steps, after)
+	return img
+}
+
+// ApplyGeometry is Apply that also returns how the steps moved the pixels
+// of img, to place what is found in the result on img
+func ApplyGeometry(img *image.Gray, steps []string, after func(i int, step string, result *image.Gray)) (*image.Gray, Geometry) {
+	var geometry Geometry
+	for i, step := range steps {
+		if filter, ok := filters[step]; ok {
+			img = filter(img)
+		} else if transform, ok := transforms[step]; ok {
+			var moved Geometry
+			img, moved = transform(img)
+			geometry = geometry.then(moved)
+		} else {
+			continue
+		}
+		if after != nil {
+			after(i, step, img)
+		}
+	}
+	return img, geometry
+}
+
+func toGray(img image.Image) *image.Gray {
+	if gray, ok := img.(*image.Gray); ok && gray.Bounds().Min == (image.Point{}) {
+		return gray
+	}
+	bounds := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(gray, gray.Bounds(), img, bounds.Min, draw.Src)
+	return gray
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
+
+// saveDebug writes the result of one step when OCR_PREPROCESS_DEBUG_DIR is set
+func saveDebug(name string, i int, step string, img *image.Gray) {
+	dir := os.Getenv("OCR_PREPROCESS_DEBUG_DIR")
+	if dir == "" {
+		return
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		log.Printf("Failed to create preprocess debug dir: %v", err)
+		return
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s_%d_%s.png", filepath.Base(name), i, step))
+	file, err := os.Create(path)
+	if err != nil {
+		log.Printf("Failed to save preprocess debug image: %v", err)
+		return
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		log.Printf("Failed to save preprocess debug image: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package preprocess
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/tiff"
+)
+
+// grayFrom builds an image from rows of pixel values
+func grayFrom(rows [][]uint8) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		copy(img.Pix[y*img.Stride:], row)
+	}
+	return img
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		env     string
+		want    []string
+		wantErr string
+	}{
+		{name: "empty", want: nil},
+		{name: "preset", value: "scan", want: Presets["scan"]},
+		{name: "preset from env", env: "fax", want: Presets["fax"]},
+		{name: "value wins over env", value: "none", env: "fax", want: nil},
+		{name: "steps", value: " grayscale, ,deskew,orient ", want: []string{StepGrayscale, StepDeskew, StepOrient}},
+		{name: "unknown step", value: "grayscale,sharpen", wantErr: "unknown preprocessing step or preset: sharpen"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OCR_PREPROCESS", tt.env)
+			steps, err := Parse(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Parse(%q) error = %v, want %q", tt.value, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.value, err)
+			}
+			if strings.Join(steps, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Parse(%q) = %q, want %q", tt.value, steps, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	img := grayFrom([][]uint8{{10, 200}, {200, 10}})
+	var seen []string
+	out := Apply(img, []string{StepOrient, StepGrayscale, StepBinarize}, func(i int, step string, result *image.Gray) {
+		seen = append(seen, step)
+	})
+	if strings.Join(seen, ",") != "grayscale,binarize" {
+		t.Errorf("Apply() ran %q, want orient skipped", seen)
+	}
+	if want := []uint8{0, 255, 255, 0}; string(out.Pix) != string(want) {
+		t.Errorf("Apply() = %v, want %v", out.Pix, want)
+	}
+}
+
+// writeImage encodes an image to a file of the test's temporary directory
+func writeImage(t *testing.T, name string, encode func(io.Writer) error) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	rgba := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	rgba.Set(0, 0, color.White)
+	rgba.Set(1, 0, color.Black)
+	path := writeImage(t, "page.png", func(w io.Writer) error { return png.Encode(w, rgba) })
+
+	img, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if want := []uint8{255, 0}; img.Rect != image.Rect(0, 0, 2, 1) || string(img.Pix) != string(want) {
+		t.Errorf("Load() = %v %v, want %v %v", img.Rect, img.Pix, image.Rect(0, 0, 2, 1), want)
+	}
+	if Oriented(path) {
+		t.Error("Oriented() = true for a PNG")
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.png")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Load() of a missing file error = %v, want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestLoadColor(t *testing.T) {
+	red, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, red)
+	img.Set(1, 0, blue)
+	encoders := map[string]func(io.Writer) error{
+		"page.png": func(w io.Writer) error { return png.Encode(w, img) },
+		"page.tif": func(w io.Writer) error { return tiff.Encode(w, img, nil) },
+	}
+	for name, encode := range encoders {
+		loaded, err := LoadColor(writeImage(t, name, encode))
+		if err != nil {
+			t.Fatalf("LoadColor(%s) error = %v", name, err)
+		}
+		if string(loaded.Pix) != string(img.Pix) {
+			t.Errorf("LoadColor(%s) = %v, want %v", name, loaded.Pix, img.Pix)
+		}
+		// a quarter turn puts red above blue
+		turned := RotateColor(loaded, 90)
+		if turned.Rect != image.Rect(0, 0, 1, 2) || turned.RGBAAt(0, 0) != red || turned.RGBAAt(0, 1) != blue {
+			t.Errorf("RotateColor(%s, 90) = %v %v, want red above blue", name, turned.Rect, turned.Pix)
+		}
+	}
+}
+
+func TestLoadUnsupportedTIFF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := tiff.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4)), nil); err != nil {
+		t.Fatal(err)
+	}
+	// mark the page JPEG compressed, which the decoder does not support
+	data := buf.Bytes()
+	ifd := int(binary.LittleEndian.Uint32(data[4:]))
+	for e := 0; e < int(binary.LittleEndian.Uint16(data[ifd:])); e++ {
+		entry := data[ifd+2+12*e:]
+		if binary.LittleEndian.Uint16(entry) == 259 {
+			binary.LittleEndian.PutUint16(entry[8:], 7)
+		}
+	}
+	path := writeImage(t, "page.tif", func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+
+	if _, err := Load(path); !errors.Is(err, image.ErrFormat) {
+		t.Errorf("Load() error = %v, want %v", err, image.ErrFormat)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
@@ -42,6 +43,58 @@ func InitQueue(channel *amqp.Channel, queueName string) (amqp.Queue, error) {
 	return queue, nil
 }
 
+// InitDelayQueue declares a queue whose messages wait delay and are then
+// dead-lettered to the target queue through the default exchange
+func InitDelayQueue(channel *amqp.Channel, queueName, target string, delay time.Duration) (amqp.Queue, error) {
+	queue, err := channel.QueueDeclare(
+		queueName,
+		true,  // durable
+		false, // auto-delete
+		false, // exclusive
+		false, // no-wait
+		delayQueueArgs(target, delay),
+	)
+	if err != nil {
+		return queue, fmt.Errorf("failed to declare delay queue: %w", err)
+	}
+	return queue, nil
+}
+
+func delayQueueArgs(target string, delay time.Duration) amqp.Table {
+	return amqp.Table{
+		"x-message-ttl":             delay.Milliseconds(),
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": target,
+	}
+}
+
+// Publisher is the publishing side of *amqp.Channel
+type Publisher interface {
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+}
+
+// Delay moves a delivery to a queue of InitDelayQueue and acks it, the
+// consumer goes on with other messages meanwhile. If the delay queue can not
+// take it, the delivery is requeued as is.
+func Delay(channel Publisher, delayQueue string, d amqp.Delivery) error {
+	err := channel.Publish(
+		"",         // exchange
+		delayQueue, // routing key
+		false,      // mandatory
+		false,      // immediate
+		amqp.Publishing{
+			ContentType:  d.ContentType,
+			DeliveryMode: d.DeliveryMode,
+			Headers:      d.Headers,
+			Body:         d.Body,
+		})
+	if err != nil {
+		d.Nack(false, true)
+		return fmt.Errorf("failed to delay message: %w", err)
+	}
+	return d.Ack(false)
+}
+
 func PublishMessage(channel *amqp.Channel, queueName string, messageBody []byte) error {
 	err := channel.Publish(
 		"",        // exchange
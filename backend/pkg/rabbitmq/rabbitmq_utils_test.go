@@ -0,0 +1,79 @@
+package rabbitmq_utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type fakePublisher struct {
+	published []amqp.Publishing
+	keys      []string
+	err       error
+}
+
+func (f *fakePublisher) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.keys = append(f.keys, key)
+	f.published = append(f.published, msg)
+	return nil
+}
+
+// fakeAcknowledger records how a delivery was settled
+type fakeAcknowledger struct {
+	acked, nacked, requeued bool
+}
+
+func (f *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	f.acked = true
+	return nil
+}
+
+func (f *fakeAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	f.nacked, f.requeued = true, requeue
+	return nil
+}
+
+func (f *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	return f.Nack(tag, false, requeue)
+}
+
+func TestDelayQueueArgs(t *testing.T) {
+	args := delayQueueArgs("translation-queue", 5*time.Second)
+	if args["x-message-ttl"] != int64(5000) || args["x-dead-letter-exchange"] != "" || args["x-dead-letter-routing-key"] != "translation-queue" {
+		t.Errorf("delayQueueArgs() = %v", args)
+	}
+	if err := args.Validate(); err != nil {
+		t.Errorf("delayQueueArgs() is not a valid table: %v", err)
+	}
+}
+
+func TestDelay(t *testing.T) {
+	ack := &fakeAcknowledger{}
+	publisher := &fakePublisher{}
+	delivery := amqp.Delivery{Acknowledger: ack, ContentType: "encoding/json", Body: []byte(`{"JobID":"a"}`)}
+
+	if err := Delay(publisher, "translation-delay-queue", delivery); err != nil {
+		t.Fatal(err)
+	}
+	if len(publisher.keys) != 1 || publisher.keys[0] != "translation-delay-queue" || string(publisher.published[0].Body) != `{"JobID":"a"}` {
+		t.Errorf("published %q to %q, want the job to translation-delay-queue", publisher.published, publisher.keys)
+	}
+	if !ack.acked || ack.nacked {
+		t.Errorf("delivery %+v, want it acked", ack)
+	}
+
+	// a delay queue that is down leaves the job in its queue
+	ack = &fakeAcknowledger{}
+	delivery.Acknowledger = ack
+	if err := Delay(&fakePublisher{err: errors.New("channel closed")}, "translation-delay-queue", delivery); err == nil {
+		t.Error("Delay() succeeded without publishing")
+	}
+	if ack.acked || !ack.requeued {
+		t.Errorf("delivery %+v, want it requeued", ack)
+	}
+}
@@ -0,0 +1,59 @@
+package redis_utils
+
+import (
+	"backend/pkg/translation"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const breakersKey = "translation_breakers"
+
+// WorkerBreakerStatus is the circuit breaker state reported by one translate worker
+type WorkerBreakerStatus struct {
+	translation.BreakerStatus
+	WorkerID  string    `json:"worker_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ReportBreakerStatus publishes the breaker state of a worker so that the
+// API servers can show it in /health
+func ReportBreakerStatus(client redis.Cmdable, ctx context.Context, workerID string, status translation.BreakerStatus) error {
+	body, err := json.Marshal(WorkerBreakerStatus{
+		BreakerStatus: status,
+		WorkerID:      workerID,
+		UpdatedAt:     time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal breaker status: %w", err)
+	}
+
+	err = client.HSet(ctx, breakersKey, workerID, body).Err()
+	if err != nil {
+		return fmt.Errorf("failed to report breaker status: %w", err)
+	}
+	return nil
+}
+
+// GetBreakerStatuses returns the breaker states reported within maxAge.
+// Older entries belong to workers that stopped and are removed.
+func GetBreakerStatuses(client redis.Cmdable, ctx context.Context, maxAge time.Duration) ([]WorkerBreakerStatus, error) {
+	entries, err := client.HGetAll(ctx, breakersKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get breaker statuses: %w", err)
+	}
+
+	var statuses []WorkerBreakerStatus
+	for workerID, body := range entries {
+		var status WorkerBreakerStatus
+		if err := json.Unmarshal([]byte(body), &status); err != nil || time.Since(status.UpdatedAt) > maxAge {
+			client.HDel(ctx, breakersKey, workerID)
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
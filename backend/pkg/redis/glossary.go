@@ -0,0 +1,58 @@
+package redis_utils
+
+import (
+	"backend/models"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrGlossaryNotFound is returned when the tenant has no glossary with the ID
+var ErrGlossaryNotFound = fmt.Errorf("glossary not found")
+
+func glossaryKey(tenantID, glossaryID string) string {
+	return fmt.Sprintf("glossary:%s:%s", tenantID, glossaryID)
+}
+
+// SaveGlossary stores the glossary under its tenant. Glossaries are immutable,
+// a changed glossary is saved under a new ID.
+func SaveGlossary(client redis.Cmdable, ctx context.Context, glossary *models.Glossary) error {
+	body, err := json.Marshal(glossary)
+	if err != nil {
+		return fmt.Errorf("failed to marshal glossary: %w", err)
+	}
+
+	err = client.Set(ctx, glossaryKey(glossary.TenantID, glossary.ID), body, 0).Err()
+	if err != nil {
+		return fmt.Errorf("failed to save glossary: %w", err)
+	}
+	return nil
+}
+
+func GetGlossary(client redis.Cmdable, ctx context.Context, tenantID, glossaryID string) (*models.Glossary, error) {
+	body, err := client.Get(ctx, glossaryKey(tenantID, glossaryID)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrGlossaryNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get glossary: %w", err)
+	}
+
+	var glossary models.Glossary
+	if err := json.Unmarshal(body, &glossary); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal glossary: %w", err)
+	}
+	return &glossary, nil
+}
+
+func DeleteGlossary(client redis.Cmdable, ctx context.Context, tenantID, glossaryID string) error {
+	deleted, err := client.Del(ctx, glossaryKey(tenantID, glossaryID)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to delete glossary: %w", err)
+	}
+	if deleted == 0 {
+		return ErrGlossaryNotFound
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package redis_utils
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	StatusCompleted          = "completed"
+	StatusFailed             = "failed"
+	StatusPartiallyCompleted = "partially_completed"
+	StatusProcessing         = "processing"
+)
+
+// InitialJobStatus returns the status hash fields of a freshly submitted job,
+// resetting the per-language progress left by an earlier run of the same job
+func InitialJobStatus(targetLangs []string) map[string]interface{} {
+	data := map[string]interface{}{
+		"response_time": 0,
+		"status":        "submitted",
+		"error":         "",
+		"langs_total":   len(targetLangs),
+		"langs_done":    0,
+		"langs_failed":  0,
+		// the upload sets pages_total of multi-page files
+		"pages_done":  0,
+		"pages_total": 1,
+		// set by the translate workers once OCR is done
+		"ocr_confidence":       "",
+		"page_confidences":     "",
+		"low_confidence":       "",
+		"orientation":          "",
+		"orientation_detected": "",
+		"script":               "",
+		"tables":               "",
+		"regions":              "",
+		"protected_spans":      "",
+	}
+	for _, lang := range targetLangs {
+		data["status:"+lang] = "pending"
+		data["error:"+lang] = ""
+		data["regions:"+lang] = ""
+		data["protected_spans:"+lang] = ""
+	}
+	return data
+}
+
+// PageDone counts one more page of the job recognized by OCR
+func PageDone(client redis.Cmdable, ctx context.Context, jobID string) error {
+	return client.HIncrBy(ctx, jobID, "pages_done", 1).Err()
+}
+
+// CompleteTargetLang records the outcome of one target language of a job.
+// Once the last language is done it sets and returns the overall status,
+// before that it returns an empty string.
+func CompleteTargetLang(client redis.Cmdable, ctx context.Context, jobID, lang, failureReason string, total int) (string, error) {
+	status := StatusCompleted
+	if failureReason != "" {
+		status = StatusFailed
+	}
+
+	err := client.HSet(ctx, jobID, "status:"+lang, status, "error:"+lang, failureReason).Err()
+	if err != nil {
+		return "", fmt.Errorf("failed to set language status: %w", err)
+	}
+
+	// failures and the intermediate status are written before the language
+	// counts as done, so whoever completes the last language sees every
+	// failure and its final status is not overwritten
+	if failureReason != "" {
+		client.HIncrBy(ctx, jobID, "langs_failed", 1)
+		client.HSet(ctx, jobID, "error", failureReason)
+	}
+	if total > 1 {
+		client.HSet(ctx, jobID, "status", StatusProcessing)
+	}
+
+	done, err := client.HIncrBy(ctx, jobID, "langs_done", 1).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to update language progress: %w", err)
+	}
+	if done < int64(total) {
+		return "", nil
+	}
+
+	failed, err := client.HGet(ctx, jobID, "langs_failed").Int64()
+	if err != nil && err != redis.Nil {
+		return "", fmt.Errorf("failed to get failed languages: %w", err)
+	}
+
+	overall := StatusCompleted
+	if failed >= int64(total) {
+		overall = StatusFailed
+	} else if failed > 0 {
+		overall = StatusPartiallyCompleted
+	}
+
+	err = client.HSet(ctx, jobID, "status", overall).Err()
+	if err != nil {
+		return "", fmt.Errorf("failed to set job status: %w", err)
+	}
+	return overall, nil
+}
+
+// LanguageProgress extracts the per-language status and errors from the
+// fields of a job status hash
+func LanguageProgress(fields map[string]string, targetLangs []string) map[string]map[string]string {
+	progress := make(map[string]map[string]string, len(targetLangs))
+	for _, lang := range targetLangs {
+		entry := map[string]string{"status": fields["status:"+lang]}
+		if reason := fields["error:"+lang]; reason != "" {
+			entry["error"] = reason
+		}
+		progress[lang] = entry
+	}
+	return progress
+}
+
+// ProtectedSpansFields returns the status hash fields of the spans kept out
+// of the translation of a job to lang, protected_spans holds those of the
+// first target language
+func ProtectedSpansFields(lang string, count int, first bool) map[string]interface{} {
+	data := map[string]interface{}{"protected_spans:" + lang: count}
+	if first {
+		data["protected_spans"] = count
+	}
+	return data
+}
+
+// ProtectedSpansByLang reads back the per-language fields of
+// ProtectedSpansFields, languages not translated yet are left out
+func ProtectedSpansByLang(fields map[string]string, targetLangs []string) map[string]int {
+	counts := make(map[string]int, len(targetLangs))
+	for _, lang := range targetLangs {
+		if count, err := strconv.Atoi(fields["protected_spans:"+lang]); err == nil {
+			counts[lang] = count
+		}
+	}
+	return counts
+}
@@ -0,0 +1,27 @@
+package redis_utils
+
+import (
+	"fmt"
+	"maps"
+	"testing"
+)
+
+func TestProtectedSpansByLang(t *testing.T) {
+	fields := map[string]string{}
+	set := func(data map[string]interface{}) {
+		for key, value := range data {
+			fields[key] = fmt.Sprint(value)
+		}
+	}
+	set(InitialJobStatus([]string{"vi", "fr", "de"}))
+	set(ProtectedSpansFields("fr", 7, false))
+	set(ProtectedSpansFields("vi", 3, true))
+
+	got := ProtectedSpansByLang(fields, []string{"vi", "fr", "de"})
+	if want := map[string]int{"vi": 3, "fr": 7}; !maps.Equal(got, want) {
+		t.Errorf("ProtectedSpansByLang() = %v, want %v", got, want)
+	}
+	if fields["protected_spans"] != "3" {
+		t.Errorf("protected_spans = %q, want 3 of the first language", fields["protected_spans"])
+	}
+}
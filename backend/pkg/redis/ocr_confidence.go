@@ -0,0 +1,34 @@
+package redis_utils
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// OCRConfidenceFields returns the status hash fields of the OCR confidence
+// of a job, pageConfidences has -1 for pages without words
+func OCRConfidenceFields(confidence float64, pageConfidences []float64, low bool) map[string]interface{} {
+	pages, _ := json.Marshal(pageConfidences)
+	return map[string]interface{}{
+		"ocr_confidence":   strconv.FormatFloat(confidence, 'f', 2, 64),
+		"page_confidences": string(pages),
+		"low_confidence":   strconv.FormatBool(low),
+	}
+}
+
+// OCRConfidence reads back the fields of OCRConfidenceFields for the status
+// response, ok is false until they are set
+func OCRConfidence(fields map[string]string) (map[string]interface{}, bool) {
+	if fields["ocr_confidence"] == "" {
+		return nil, false
+	}
+	confidence, _ := strconv.ParseFloat(fields["ocr_confidence"], 64)
+	var pages []float64
+	json.Unmarshal([]byte(fields["page_confidences"]), &pages)
+	low, _ := strconv.ParseBool(fields["low_confidence"])
+	return map[string]interface{}{
+		"ocr_confidence":   confidence,
+		"page_confidences": pages,
+		"low_confidence":   low,
+	}, true
+}
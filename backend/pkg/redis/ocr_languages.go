@@ -0,0 +1,37 @@
+package redis_utils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ocrLanguagesKey holds the Tesseract languages installed on the OCR workers
+const ocrLanguagesKey = "ocr_languages"
+
+// PublishOCRLanguages adds the languages installed on an OCR worker to the
+// set the API servers check uploads against
+func PublishOCRLanguages(client redis.Cmdable, ctx context.Context, langs []string) error {
+	if len(langs) == 0 {
+		return nil
+	}
+	members := make([]interface{}, len(langs))
+	for i, lang := range langs {
+		members[i] = lang
+	}
+	if err := client.SAdd(ctx, ocrLanguagesKey, members...).Err(); err != nil {
+		return fmt.Errorf("failed to publish OCR languages: %w", err)
+	}
+	return nil
+}
+
+// OCRLanguages returns the languages published by the OCR workers. It is
+// empty until a worker has started.
+func OCRLanguages(client redis.Cmdable, ctx context.Context) ([]string, error) {
+	langs, err := client.SMembers(ctx, ocrLanguagesKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OCR languages: %w", err)
+	}
+	return langs, nil
+}
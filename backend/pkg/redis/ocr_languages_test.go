@@ -0,0 +1,37 @@
+package redis_utils
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestOCRLanguages(t *testing.T) {
+	_, client := newTestClient(t)
+	ctx := context.Background()
+
+	langs, err := OCRLanguages(client, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(langs) != 0 {
+		t.Fatalf("OCRLanguages() before any worker = %v, want none", langs)
+	}
+
+	// two workers with different languages installed
+	if err := PublishOCRLanguages(client, ctx, []string{"eng", "osd"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := PublishOCRLanguages(client, ctx, []string{"eng", "vie"}); err != nil {
+		t.Fatal(err)
+	}
+
+	langs, err = OCRLanguages(client, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(langs)
+	if want := []string{"eng", "osd", "vie"}; !slices.Equal(langs, want) {
+		t.Errorf("OCRLanguages() = %v, want %v", langs, want)
+	}
+}
@@ -0,0 +1,90 @@
+package redis_utils
+
+import (
+	"backend/pkg/translation"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript takes tokens from two buckets (requests and characters)
+// atomically, or from neither. Each bucket is a hash of its token count and
+// last refill time in ms; the Redis clock is used so that workers on hosts
+// with skewed clocks share one budget. Returns 0 once the tokens are taken,
+// otherwise the ms until both buckets can cover the request.
+var tokenBucketScript = redis.NewScript(`
+redis.replicate_commands()
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local wait = 0
+local levels = {}
+for i = 1, 2 do
+	local rate = tonumber(ARGV[i * 3 - 2])
+	local capacity = tonumber(ARGV[i * 3 - 1])
+	local requested = math.min(tonumber(ARGV[i * 3]), capacity)
+	if rate > 0 then
+		local state = redis.call('HMGET', KEYS[i], 'tokens', 'ts')
+		local tokens = tonumber(state[1]) or capacity
+		local ts = tonumber(state[2]) or now
+		tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate / 1000)
+		levels[i] = tokens - requested
+		if tokens < requested then
+			wait = math.max(wait, math.ceil((requested - tokens) * 1000 / rate))
+		end
+	end
+end
+if wait > 0 then
+	return wait
+end
+for i = 1, 2 do
+	if levels[i] then
+		redis.call('HSET', KEYS[i], 'tokens', levels[i], 'ts', now)
+		redis.call('PEXPIRE', KEYS[i], 3600000)
+	end
+end
+return 0
+`)
+
+// RateLimiter is a token bucket per backend stored in Redis, shared by all
+// translate workers. It only needs redis.Cmdable, so it works with both
+// *redis.Client and *redis.ClusterClient.
+type RateLimiter struct {
+	client redis.Cmdable
+	ctx    context.Context
+	limit  translation.RateLimit
+}
+
+func NewRateLimiter(client redis.Cmdable, ctx context.Context, limit translation.RateLimit) *RateLimiter {
+	if limit.Burst <= 0 {
+		limit.Burst = time.Second
+	}
+	return &RateLimiter{client: client, ctx: ctx, limit: limit}
+}
+
+// rateLimitKeys share the {backend} hash tag, the script touches both keys
+// and they must live in the same cluster slot
+func rateLimitKeys(backend string) []string {
+	return []string{
+		fmt.Sprintf("ratelimit:{%s}:requests", backend),
+		fmt.Sprintf("ratelimit:{%s}:chars", backend),
+	}
+}
+
+// Reserve implements translation.RateLimiter
+func (l *RateLimiter) Reserve(backend string, chars int) (time.Duration, error) {
+	burst := l.limit.Burst.Seconds()
+	// a single call larger than the bucket waits for a full bucket
+	requestCapacity := max(l.limit.RequestsPerSec*burst, 1)
+	charCapacity := max(l.limit.CharsPerSec*burst, 1)
+
+	wait, err := tokenBucketScript.Run(l.ctx, l.client, rateLimitKeys(backend),
+		l.limit.RequestsPerSec, requestCapacity, 1,
+		l.limit.CharsPerSec, charCapacity, chars,
+	).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to reserve translation budget: %w", err)
+	}
+	return time.Duration(wait) * time.Millisecond, nil
+}
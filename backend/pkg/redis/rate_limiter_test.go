@@ -0,0 +1,50 @@
+package redis_utils
+
+import (
+	"backend/pkg/translation"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	server, client := newTestClient(t)
+	now := time.Unix(1700000000, 0)
+	server.SetTime(now)
+
+	// 2 requests and 100 characters a second, a burst of one second
+	limiter := NewRateLimiter(client, context.Background(), translation.RateLimit{RequestsPerSec: 2, CharsPerSec: 100})
+	steps := []struct {
+		advance time.Duration
+		chars   int
+		want    time.Duration
+	}{
+		{0, 40, 0},
+		{0, 40, 0},
+		// both requests are spent, one comes back in 500ms
+		{0, 10, 500 * time.Millisecond},
+		{500 * time.Millisecond, 10, 0},
+		// 60 characters are left, 20 more take 200ms but the next request 500ms
+		{0, 80, 500 * time.Millisecond},
+		{250 * time.Millisecond, 10, 250 * time.Millisecond},
+		// a call larger than the bucket waits for a full bucket
+		{2 * time.Second, 500, 0},
+		{0, 1, 10 * time.Millisecond},
+	}
+	for i, step := range steps {
+		now = now.Add(step.advance)
+		server.SetTime(now)
+		wait, err := limiter.Reserve("google", step.chars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wait != step.want {
+			t.Errorf("step %d: Reserve(%d) = %v, want %v", i, step.chars, wait, step.want)
+		}
+	}
+
+	// other backends have a budget of their own
+	if wait, _ := limiter.Reserve("libretranslate", 100); wait != 0 {
+		t.Errorf("Reserve() of another backend = %v, want 0", wait)
+	}
+}
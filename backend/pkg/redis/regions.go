@@ -0,0 +1,37 @@
+package redis_utils
+
+import (
+	"backend/models"
+	"encoding/json"
+)
+
+// RegionFields returns the status hash fields of the regions of interest of
+// a job translated to lang: the recognized texts and their translations
+func RegionFields(lang string, doc *models.Document) map[string]interface{} {
+	texts, _ := json.Marshal(doc.RegionTexts())
+	translations, _ := json.Marshal(doc.RegionTranslations())
+	return map[string]interface{}{
+		"regions":         string(texts),
+		"regions:" + lang: string(translations),
+	}
+}
+
+// Regions reads back the fields of RegionFields for the status response, ok
+// is false until they are set
+func Regions(fields map[string]string, targetLangs []string) (map[string]models.RegionResult, bool) {
+	if fields["regions"] == "" {
+		return nil, false
+	}
+	var texts map[string]string
+	if err := json.Unmarshal([]byte(fields["regions"]), &texts); err != nil {
+		return nil, false
+	}
+	translations := map[string]map[string]string{}
+	for _, lang := range targetLangs {
+		var translated map[string]string
+		if json.Unmarshal([]byte(fields["regions:"+lang]), &translated) == nil {
+			translations[lang] = translated
+		}
+	}
+	return models.RegionResults(texts, translations), true
+}
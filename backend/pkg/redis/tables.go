@@ -0,0 +1,30 @@
+package redis_utils
+
+import (
+	"backend/models"
+	"encoding/json"
+)
+
+// TablesField returns the status hash field of the tables of a job, the
+// cell texts of each table by row
+func TablesField(tables []*models.Table) string {
+	cells := make([][][]string, 0, len(tables))
+	for _, table := range tables {
+		cells = append(cells, table.Cells())
+	}
+	data, _ := json.Marshal(cells)
+	return string(data)
+}
+
+// Tables reads back the field of TablesField for the status response, ok is
+// false until it is set
+func Tables(fields map[string]string) ([][][]string, bool) {
+	if fields["tables"] == "" {
+		return nil, false
+	}
+	var cells [][][]string
+	if err := json.Unmarshal([]byte(fields["tables"]), &cells); err != nil {
+		return nil, false
+	}
+	return cells, true
+}
@@ -0,0 +1,87 @@
+package redis_utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	memoryKeyPrefix = "tm:"
+	memoryHitsKey   = "tm_stats:hits"
+	memoryMissesKey = "tm_stats:misses"
+)
+
+// TranslationMemory caches translated segments in Redis. It only needs
+// redis.Cmdable, so it works with both *redis.Client and *redis.ClusterClient.
+type TranslationMemory struct {
+	client redis.Cmdable
+	ctx    context.Context
+	ttl    time.Duration
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+func NewTranslationMemory(client redis.Cmdable, ctx context.Context, ttl time.Duration) *TranslationMemory {
+	return &TranslationMemory{client: client, ctx: ctx, ttl: ttl}
+}
+
+// normalizeSegment collapses whitespace so that the same paragraph broken
+// over different lines maps to the same entry
+func normalizeSegment(segment string) string {
+	return strings.Join(strings.Fields(segment), " ")
+}
+
+func memoryKey(segment, sourceLang, targetLang, backend string) string {
+	hash := sha256.Sum256([]byte(normalizeSegment(segment)))
+	return fmt.Sprintf("%s%s:%s:%s:%x", memoryKeyPrefix, backend, sourceLang, targetLang, hash)
+}
+
+// Lookup returns the stored translation of segment, if any
+func (m *TranslationMemory) Lookup(segment, sourceLang, targetLang, backend string) (string, bool) {
+	translated, err := m.client.Get(m.ctx, memoryKey(segment, sourceLang, targetLang, backend)).Result()
+	if err != nil {
+		m.misses.Add(1)
+		m.client.Incr(m.ctx, memoryMissesKey)
+		return "", false
+	}
+
+	m.hits.Add(1)
+	m.client.Incr(m.ctx, memoryHitsKey)
+	return translated, true
+}
+
+// Store saves the translation of segment for the configured TTL
+func (m *TranslationMemory) Store(segment, sourceLang, targetLang, backend, translated string) error {
+	err := m.client.Set(m.ctx, memoryKey(segment, sourceLang, targetLang, backend), translated, m.ttl).Err()
+	if err != nil {
+		return fmt.Errorf("failed to store translation memory entry: %w", err)
+	}
+	return nil
+}
+
+// LocalStats returns the hits and misses seen by this process
+func (m *TranslationMemory) LocalStats() (int64, int64) {
+	return m.hits.Load(), m.misses.Load()
+}
+
+// GetTranslationMemoryStats returns the hits and misses of all workers
+func GetTranslationMemoryStats(client redis.Cmdable, ctx context.Context) (int64, int64, error) {
+	hits, err := client.Get(ctx, memoryHitsKey).Int64()
+	if err != nil && err != redis.Nil {
+		return 0, 0, fmt.Errorf("failed to get translation memory hits: %v", err)
+	}
+
+	misses, err := client.Get(ctx, memoryMissesKey).Int64()
+	if err != nil && err != redis.Nil {
+		return 0, 0, fmt.Errorf("failed to get translation memory misses: %v", err)
+	}
+
+	return hits, misses, nil
+}
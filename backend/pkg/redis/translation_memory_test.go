@@ -0,0 +1,65 @@
+package redis_utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestClient returns a client of an in-memory Redis server
+func newTestClient(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return server, client
+}
+
+func TestTranslationMemory(t *testing.T) {
+	server, client := newTestClient(t)
+	ctx := context.Background()
+	memory := NewTranslationMemory(client, ctx, time.Hour)
+
+	if err := memory.Store("Hello  world\n", "en", "vi", "google", "Xin chào thế giới"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		segment, targetLang, backend string
+		want                         string
+		wantOK                       bool
+	}{
+		{"Hello world", "vi", "google", "Xin chào thế giới", true},
+		{" Hello\nworld ", "vi", "google", "Xin chào thế giới", true},
+		{"Hello world", "fr", "google", "", false},
+		{"Hello world", "vi", "libretranslate", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := memory.Lookup(tt.segment, "en", tt.targetLang, tt.backend)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Lookup(%q, %s, %s) = %q, %v, want %q, %v", tt.segment, tt.targetLang, tt.backend, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	if hits, misses := memory.LocalStats(); hits != 2 || misses != 2 {
+		t.Errorf("LocalStats() = %d, %d, want 2, 2", hits, misses)
+	}
+	if hits, misses, err := GetTranslationMemoryStats(client, ctx); err != nil || hits != 2 || misses != 2 {
+		t.Errorf("GetTranslationMemoryStats() = %d, %d, %v, want 2, 2", hits, misses, err)
+	}
+
+	server.FastForward(2 * time.Hour)
+	if _, ok := memory.Lookup("Hello world", "en", "vi", "google"); ok {
+		t.Error("Lookup() found an entry past its TTL")
+	}
+}
+
+func TestTranslationMemoryStatsEmpty(t *testing.T) {
+	_, client := newTestClient(t)
+	if hits, misses, err := GetTranslationMemoryStats(client, context.Background()); err != nil || hits != 0 || misses != 0 {
+		t.Errorf("GetTranslationMemoryStats() = %d, %d, %v, want 0, 0", hits, misses, err)
+	}
+}
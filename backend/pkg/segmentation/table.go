@@ -0,0 +1,223 @@
+package segmentation
+
+import (
+	"image"
+	"slices"
+)
+
+// inkThreshold is the gray level below which a pixel belongs to a rule
+const inkThreshold = 128
+
+// ruleGap is the longest break, in pixels, a scanned rule may have
+const ruleGap = 2
+
+// Table is a ruled table. Rows and Cols are the centers of its horizontal
+// and vertical rules, top to bottom and left to right, cell (r, c) lies
+// between Rows[r] and Rows[r+1], Cols[c] and Cols[c+1]. A merged cell is
+// split along the rules of its neighbours.
+type Table struct {
+	Bounds image.Rectangle
+	Rows   []int
+	Cols   []int
+	// inset keeps the rules out of the cells
+	inset int
+}
+
+// Cell returns the inside of cell (r, c), without its rules
+func (t Table) Cell(r, c int) image.Rectangle {
+	cell := image.Rect(t.Cols[c], t.Rows[r], t.Cols[c+1], t.Rows[r+1]).Inset(t.inset)
+	if cell.Empty() {
+		return image.Rectangle{}
+	}
+	return cell
+}
+
+// rule is a horizontal or vertical line of the image. first and last are
+// its rows (columns of a vertical rule), from and to its extent along.
+type rule struct {
+	first, last int
+	from, to    int
+}
+
+func (r rule) pos() int {
+	return (r.first + r.last) / 2
+}
+
+func (r rule) thickness() int {
+	return r.last - r.first + 1
+}
+
+// crosses reports whether the horizontal rule h and the vertical rule v
+// meet, give or take tolerance pixels
+func crosses(h, v rule, tolerance int) bool {
+	return v.pos() >= h.from-tolerance && v.pos() <= h.to+tolerance &&
+		h.pos() >= v.from-tolerance && h.pos() <= v.to+tolerance
+}
+
+// DetectTables finds the ruled tables of img: horizontal and vertical line
+// projections give the rules, rules crossing each other make up a table.
+// Tables without vertical rules, or of a single cell, such as a frame
+// around a paragraph, are not found.
+func DetectTables(img *image.Gray) []Table {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	dark := func(x, y int) bool {
+		return img.Pix[y*img.Stride+x] < inkThreshold
+	}
+	horizontal := findRules(height, width, max(width/10, 40), func(row, i int) bool { return dark(i, row) })
+	vertical := findRules(width, height, max(height/60, 15), func(col, i int) bool { return dark(col, i) })
+	if len(horizontal) < 2 || len(vertical) < 2 {
+		return nil
+	}
+
+	// rules crossing each other belong to the same table
+	parent := make([]int, len(horizontal)+len(vertical))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	tolerance := 4
+	crossings := make([]int, len(parent))
+	for h, hRule := range horizontal {
+		for v, vRule := range vertical {
+			if crosses(hRule, vRule, tolerance+max(hRule.thickness(), vRule.thickness())) {
+				parent[find(h)] = find(len(horizontal) + v)
+				crossings[h]++
+				crossings[len(horizontal)+v]++
+			}
+		}
+	}
+
+	// a rule of a table crosses two others at least, a letter stroke
+	// touching a rule or an underline crosses one
+	groups := map[int][]int{}
+	for i := range parent {
+		if crossings[i] >= 2 {
+			groups[find(i)] = append(groups[find(i)], i)
+		}
+	}
+	var tables []Table
+	for _, members := range groups {
+		if table, ok := buildTable(members, horizontal, vertical, tolerance); ok {
+			table.Bounds = table.Bounds.Add(bounds.Min)
+			for i := range table.Rows {
+				table.Rows[i] += bounds.Min.Y
+			}
+			for i := range table.Cols {
+				table.Cols[i] += bounds.Min.X
+			}
+			tables = append(tables, table)
+		}
+	}
+	// reading order
+	slices.SortFunc(tables, func(a, b Table) int {
+		if a.Bounds.Min.Y != b.Bounds.Min.Y {
+			return a.Bounds.Min.Y - b.Bounds.Min.Y
+		}
+		return a.Bounds.Min.X - b.Bounds.Min.X
+	})
+	return tables
+}
+
+// buildTable makes a table of the crossing rules members, indexes into the
+// horizontal then the vertical rules
+func buildTable(members []int, horizontal, vertical []rule, tolerance int) (Table, bool) {
+	var table Table
+	thickest := 0
+	for _, i := range members {
+		var r rule
+		if i < len(horizontal) {
+			r = horizontal[i]
+			table.Rows = append(table.Rows, r.pos())
+			table.Bounds = table.Bounds.Union(image.Rect(r.from, r.first, r.to+1, r.last+1))
+		} else {
+			r = vertical[i-len(horizontal)]
+			table.Cols = append(table.Cols, r.pos())
+			table.Bounds = table.Bounds.Union(image.Rect(r.first, r.from, r.last+1, r.to+1))
+		}
+		thickest = max(thickest, r.thickness())
+	}
+	table.Rows = mergeClose(table.Rows, tolerance+thickest)
+	table.Cols = mergeClose(table.Cols, tolerance+thickest)
+	table.inset = thickest/2 + 2
+	if len(table.Rows) < 2 || len(table.Cols) < 2 || (len(table.Rows)-1)*(len(table.Cols)-1) < 2 {
+		return Table{}, false
+	}
+	return table, true
+}
+
+// mergeClose sorts positions and keeps one of those closer than distance,
+// pieces of one rule broken by skew or noise
+func mergeClose(positions []int, distance int) []int {
+	slices.Sort(positions)
+	var merged []int
+	for _, p := range positions {
+		if len(merged) > 0 && p-merged[len(merged)-1] < distance {
+			continue
+		}
+		merged = append(merged, p)
+	}
+	return merged
+}
+
+// findRules returns the rules of one direction. lines is the number of
+// rows (columns) scanned and length their length; ink(line, i) reports
+// whether pixel i of the line is dark. Runs of at least minLength dark
+// pixels on neighbouring lines are joined into a rule, which must be thin
+// for its length: a dark area is not a rule.
+func findRules(lines, length, minLength int, ink func(line, i int) bool) []rule {
+	var rules []rule
+	// open holds the indexes of the rules that reached the previous line
+	var open, next []int
+	for line := 0; line < lines; line++ {
+		next = next[:0]
+		for i := 0; i < length; {
+			if !ink(line, i) {
+				i++
+				continue
+			}
+			start, end := i, i
+			for gap := 0; i < length && gap <= ruleGap; i++ {
+				if ink(line, i) {
+					end, gap = i, 0
+				} else {
+					gap++
+				}
+			}
+			if end-start+1 < minLength {
+				continue
+			}
+
+			joined := false
+			for _, r := range open {
+				if rules[r].last == line-1 && start <= rules[r].to+ruleGap && end >= rules[r].from-ruleGap {
+					rules[r].last = line
+					rules[r].from = min(rules[r].from, start)
+					rules[r].to = max(rules[r].to, end)
+					next = append(next, r)
+					joined = true
+					break
+				}
+			}
+			if !joined {
+				rules = append(rules, rule{first: line, last: line, from: start, to: end})
+				next = append(next, len(rules)-1)
+			}
+		}
+		open, next = next, open
+	}
+
+	thin := rules[:0]
+	for _, r := range rules {
+		if r.thickness() <= max(8, (r.to-r.from+1)/40) {
+			thin = append(thin, r)
+		}
+	}
+	return thin
+}
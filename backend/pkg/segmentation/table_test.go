@@ -0,0 +1,219 @@
+package segmentation
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"slices"
+	"testing"
+)
+
+// page returns a white image of width by height
+func page(width, height int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	return img
+}
+
+func fill(img *image.Gray, r image.Rectangle) {
+	draw.Draw(img, r, image.NewUniform(color.Black), image.Point{}, draw.Src)
+}
+
+// grid draws rules at rows and cols, thickness pixels wide, the horizontal
+// ones dropping by slope pixels per pixel to the right
+func grid(img *image.Gray, rows, cols []int, thickness int, slope float64) {
+	left, right := cols[0], cols[len(cols)-1]+thickness
+	top, bottom := rows[0], rows[len(rows)-1]+thickness
+	for _, y := range rows {
+		for x := left; x < right; x++ {
+			dy := int(float64(x-left) * slope)
+			fill(img, image.Rect(x, y+dy, x+1, y+dy+thickness))
+		}
+	}
+	for _, x := range cols {
+		fill(img, image.Rect(x, top, x+thickness, bottom+int(float64(x-left)*slope)))
+	}
+}
+
+// text draws a line of letter-like strokes
+func text(img *image.Gray, x, y, letters int) {
+	for i := 0; i < letters; i++ {
+		fill(img, image.Rect(x+9*i, y, x+9*i+6, y+12))
+	}
+}
+
+// wantTable is a table DetectTables should find: its rules, where the
+// rules of a thick line are its middle, and the inside of its first cell
+type wantTable struct {
+	bounds     image.Rectangle
+	rows, cols []int
+	firstCell  image.Rectangle
+}
+
+func checkTables(t *testing.T, got []Table, want []wantTable) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("DetectTables() found %d tables, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		table := got[i]
+		if table.Bounds != w.bounds {
+			t.Errorf("table %d Bounds = %v, want %v", i, table.Bounds, w.bounds)
+		}
+		if !slices.Equal(table.Rows, w.rows) || !slices.Equal(table.Cols, w.cols) {
+			t.Errorf("table %d rules = rows %v, cols %v, want rows %v, cols %v", i, table.Rows, table.Cols, w.rows, w.cols)
+		}
+		if cell := table.Cell(0, 0); cell != w.firstCell {
+			t.Errorf("table %d Cell(0, 0) = %v, want %v", i, cell, w.firstCell)
+		}
+	}
+}
+
+func TestDetectTables(t *testing.T) {
+	tests := []struct {
+		name string
+		draw func(img *image.Gray)
+		want []wantTable
+		// the rules of a skewed table run into the edges of its cells
+		skewed bool
+	}{
+		{
+			name: "ruled grid",
+			draw: func(img *image.Gray) {
+				grid(img, []int{100, 160, 220, 280}, []int{50, 250, 450, 650}, 2, 0)
+				text(img, 60, 120, 10)
+				text(img, 260, 180, 5)
+			},
+			want: []wantTable{{
+				bounds:    image.Rect(50, 100, 652, 282),
+				rows:      []int{100, 160, 220, 280},
+				cols:      []int{50, 250, 450, 650},
+				firstCell: image.Rect(53, 103, 247, 157),
+			}},
+		},
+		{
+			name: "thick rules",
+			draw: func(img *image.Gray) {
+				grid(img, []int{100, 200, 300}, []int{100, 400, 700}, 6, 0)
+			},
+			want: []wantTable{{
+				bounds:    image.Rect(100, 100, 706, 306),
+				rows:      []int{102, 202, 302},
+				cols:      []int{102, 402, 702},
+				firstCell: image.Rect(107, 107, 397, 197),
+			}},
+		},
+		{
+			name: "slightly skewed",
+			draw: func(img *image.Gray) {
+				grid(img, []int{100, 180, 260}, []int{100, 300, 500, 700}, 2, 0.005)
+			},
+			want: []wantTable{{
+				bounds:    image.Rect(100, 100, 702, 265),
+				rows:      []int{101, 181, 261},
+				cols:      []int{100, 300, 500, 700},
+				firstCell: image.Rect(104, 105, 296, 177),
+			}},
+			skewed: true,
+		},
+		{
+			name: "two tables",
+			draw: func(img *image.Gray) {
+				grid(img, []int{300, 360, 420}, []int{50, 300, 550}, 2, 0)
+				grid(img, []int{50, 110, 170}, []int{400, 550, 700}, 2, 0)
+			},
+			// in reading order, the upper table first
+			want: []wantTable{
+				{
+					bounds:    image.Rect(400, 50, 702, 172),
+					rows:      []int{50, 110, 170},
+					cols:      []int{400, 550, 700},
+					firstCell: image.Rect(403, 53, 547, 107),
+				},
+				{
+					bounds:    image.Rect(50, 300, 552, 422),
+					rows:      []int{300, 360, 420},
+					cols:      []int{50, 300, 550},
+					firstCell: image.Rect(53, 303, 297, 357),
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := page(800, 600)
+			tt.draw(img)
+			tables := DetectTables(img)
+			checkTables(t, tables, tt.want)
+			if tt.skewed {
+				return
+			}
+			// the cells keep clear of the rules
+			for _, table := range tables {
+				for r := 0; r < len(table.Rows)-1; r++ {
+					for c := 0; c < len(table.Cols)-1; c++ {
+						cell := table.Cell(r, c)
+						for x := cell.Min.X; x < cell.Max.X; x++ {
+							if img.GrayAt(x, cell.Min.Y).Y < inkThreshold || img.GrayAt(x, cell.Max.Y-1).Y < inkThreshold {
+								t.Fatalf("cell (%d, %d) %v touches a rule", r, c, cell)
+							}
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDetectTablesRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		draw func(img *image.Gray)
+	}{
+		{"blank page", func(img *image.Gray) {}},
+		{"text only", func(img *image.Gray) {
+			for y := 50; y < 500; y += 30 {
+				text(img, 50, y, 60)
+			}
+		}},
+		{"frame around a paragraph", func(img *image.Gray) {
+			grid(img, []int{100, 300}, []int{100, 700}, 2, 0)
+			text(img, 120, 150, 50)
+		}},
+		{"horizontal rules only", func(img *image.Gray) {
+			for _, y := range []int{100, 200, 300} {
+				fill(img, image.Rect(50, y, 750, y+2))
+			}
+		}},
+		{"underlined text", func(img *image.Gray) {
+			text(img, 100, 100, 40)
+			fill(img, image.Rect(100, 114, 460, 116))
+		}},
+		{"dark photo", func(img *image.Gray) {
+			fill(img, image.Rect(100, 100, 500, 400))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := page(800, 600)
+			tt.draw(img)
+			if tables := DetectTables(img); len(tables) != 0 {
+				t.Errorf("DetectTables() found %d tables: %+v", len(tables), tables)
+			}
+		})
+	}
+}
+
+func TestDetectTablesOffsetImage(t *testing.T) {
+	img := page(800, 600)
+	grid(img, []int{100, 160, 220}, []int{50, 250, 450}, 2, 0)
+	sub := img.SubImage(image.Rect(20, 40, 800, 600)).(*image.Gray)
+
+	// the rules are in the coordinates of the image, not of sub
+	checkTables(t, DetectTables(sub), []wantTable{{
+		bounds:    image.Rect(50, 100, 452, 222),
+		rows:      []int{100, 160, 220},
+		cols:      []int{50, 250, 450},
+		firstCell: image.Rect(53, 103, 247, 157),
+	}})
+}
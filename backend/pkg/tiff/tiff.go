@@ -0,0 +1,317 @@
+// Package tiff splits multi-page TIFF files into single-page ones.
+//
+// Tesseract only reads the first page of a TIFF and the standard library has
+// no TIFF decoder, so the pages are not decoded: each image file directory
+// (IFD) is copied, with the strips or tiles it points to, into a TIFF file of
+// its own, which Tesseract then reads.
+package tiff
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// maxPages bounds the pages read from one file, a broken IFD chain may loop
+const maxPages = 1000
+
+const (
+	tagImageWidth      = 256
+	tagImageLength     = 257
+	tagStripOffsets    = 273
+	tagStripByteCounts = 279
+	tagTileOffsets     = 324
+	tagTileByteCounts  = 325
+	tagSubIFDs         = 330
+	tagExifIFD         = 34665
+	tagGPSIFD          = 34853
+	tagInteropIFD      = 40965
+)
+
+const (
+	typeShort = 3
+	typeLong  = 4
+)
+
+// typeSizes are the sizes in bytes of the TIFF field types
+var typeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8, 13: 4}
+
+var ErrNotTIFF = errors.New("not a TIFF file")
+
+// IsTIFF reports whether the file at path starts with a TIFF header
+func IsTIFF(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return false
+	}
+	return byteOrder(header) != nil
+}
+
+func byteOrder(header []byte) binary.ByteOrder {
+	switch {
+	case len(header) < 4:
+		return nil
+	case string(header[:4]) == "II*\x00":
+		return binary.LittleEndian
+	case string(header[:4]) == "MM\x00*":
+		return binary.BigEndian
+	default:
+		return nil
+	}
+}
+
+type entry struct {
+	tag, typ uint16
+	count    uint32
+	// value holds the bytes of the field, read from its offset when they do
+	// not fit in the entry
+	value []byte
+}
+
+func (e entry) uints(order binary.ByteOrder) []uint32 {
+	values := make([]uint32, 0, e.count)
+	for i := 0; i < int(e.count); i++ {
+		switch e.typ {
+		case typeShort:
+			values = append(values, uint32(order.Uint16(e.value[2*i:])))
+		case typeLong:
+			values = append(values, order.Uint32(e.value[4*i:]))
+		}
+	}
+	return values
+}
+
+// ifd is one page
+type ifd struct {
+	entries []entry
+}
+
+func (d ifd) find(tag uint16) (entry, bool) {
+	for _, e := range d.entries {
+		if e.tag == tag {
+			return e, true
+		}
+	}
+	return entry{}, false
+}
+
+// readIFDs reads the directories of every page of the file
+func readIFDs(data []byte) (binary.ByteOrder, []ifd, error) {
+	order := byteOrder(data)
+	if order == nil || len(data) < 8 {
+		return nil, nil, ErrNotTIFF
+	}
+
+	var pages []ifd
+	visited := map[uint32]bool{}
+	for offset := order.Uint32(data[4:]); offset != 0; {
+		if visited[offset] || len(pages) >= maxPages {
+			return nil, nil, fmt.Errorf("tiff: looping IFD chain")
+		}
+		visited[offset] = true
+		if int(offset)+2 > len(data) {
+			return nil, nil, fmt.Errorf("tiff: IFD offset out of range")
+		}
+
+		count := int(order.Uint16(data[offset:]))
+		start := int(offset) + 2
+		if start+12*count+4 > len(data) {
+			return nil, nil, fmt.Errorf("tiff: truncated IFD")
+		}
+		var page ifd
+		for i := 0; i < count; i++ {
+			raw := data[start+12*i : start+12*i+12]
+			e := entry{tag: order.Uint16(raw), typ: order.Uint16(raw[2:]), count: order.Uint32(raw[4:])}
+			size, ok := typeSizes[e.typ]
+			if !ok {
+				continue
+			}
+			length := size * int(e.count)
+			if length <= 4 {
+				e.value = append([]byte(nil), raw[8:8+length]...)
+			} else {
+				at := int(order.Uint32(raw[8:]))
+				if at+length > len(data) || at+length < at {
+					return nil, nil, fmt.Errorf("tiff: field %d out of range", e.tag)
+				}
+				e.value = data[at : at+length]
+			}
+			page.entries = append(page.entries, e)
+		}
+		pages = append(pages, page)
+		offset = order.Uint32(data[start+12*count:])
+	}
+	if len(pages) == 0 {
+		return nil, nil, fmt.Errorf("tiff: no pages")
+	}
+	return order, pages, nil
+}
+
+// CountPages returns the number of pages of a TIFF file read from r
+func CountPages(r io.Reader) (int, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil || byteOrder(header) == nil {
+		return 0, ErrNotTIFF
+	}
+	rest, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	data := append(header, rest...)
+	_, pages, err := readIFDs(data)
+	return len(pages), err
+}
+
+// DecodeConfig returns the size of the first page, image.DecodeConfig can
+// not read TIFF files
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	order, pages, err := readIFDs(data)
+	if err != nil {
+		return image.Config{}, err
+	}
+	width, height := pages[0].size(order)
+	return image.Config{Width: width, Height: height}, nil
+}
+
+func (d ifd) size(order binary.ByteOrder) (int, int) {
+	var width, height int
+	if e, ok := d.find(tagImageWidth); ok {
+		if values := e.uints(order); len(values) > 0 {
+			width = int(values[0])
+		}
+	}
+	if e, ok := d.find(tagImageLength); ok {
+		if values := e.uints(order); len(values) > 0 {
+			height = int(values[0])
+		}
+	}
+	return width, height
+}
+
+// Split writes every page of the TIFF file at path to dir as
+// <prefix>_page_<n>.tif and returns their paths in page order
+func Split(path, dir, prefix string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TIFF: %w", err)
+	}
+	order, pages, err := readIFDs(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create page dir: %w", err)
+	}
+
+	paths := make([]string, 0, len(pages))
+	for i, page := range pages {
+		out, err := page.encode(data, order)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+		pagePath := filepath.Join(dir, fmt.Sprintf("%s_page_%d.tif", prefix, i+1))
+		if err := os.WriteFile(pagePath, out, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write page %d: %w", i+1, err)
+		}
+		paths = append(paths, pagePath)
+	}
+	return paths, nil
+}
+
+// encode writes the page as a single-page TIFF: the header, the IFD, the
+// fields too long for their entry and the image data, with every offset
+// rewritten
+func (d ifd) encode(data []byte, order binary.ByteOrder) ([]byte, error) {
+	// directories of the original file can not be followed from the copy
+	var entries []entry
+	for _, e := range d.entries {
+		switch e.tag {
+		case tagSubIFDs, tagExifIFD, tagGPSIFD, tagInteropIFD:
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	offsetsTag, countsTag := uint16(tagStripOffsets), uint16(tagStripByteCounts)
+	if _, ok := d.find(tagStripOffsets); !ok {
+		offsetsTag, countsTag = tagTileOffsets, tagTileByteCounts
+	}
+	offsetsEntry, ok := d.find(offsetsTag)
+	countsEntry, ok2 := d.find(countsTag)
+	if !ok || !ok2 || offsetsEntry.count != countsEntry.count {
+		return nil, fmt.Errorf("tiff: page without image data")
+	}
+	chunkOffsets := offsetsEntry.uints(order)
+	chunkCounts := countsEntry.uints(order)
+	if len(chunkOffsets) != len(chunkCounts) {
+		return nil, fmt.Errorf("tiff: invalid image data offsets")
+	}
+
+	// the offsets are rewritten as LONGs, the new ones may not fit a SHORT
+	for i := range entries {
+		if entries[i].tag == offsetsTag {
+			entries[i] = entry{tag: offsetsTag, typ: typeLong, count: offsetsEntry.count, value: make([]byte, 4*offsetsEntry.count)}
+		}
+	}
+
+	ifdSize := 2 + 12*len(entries) + 4
+	out := make([]byte, 8+ifdSize)
+	copy(out, data[:4])
+	order.PutUint32(out[4:], 8)
+	order.PutUint16(out[8:], uint16(len(entries)))
+
+	// where the value of each entry ends up, for the offsets patched below
+	valueAt := make([]int, len(entries))
+	for i, e := range entries {
+		raw := out[10+12*i : 10+12*i+12]
+		order.PutUint16(raw, e.tag)
+		order.PutUint16(raw[2:], e.typ)
+		order.PutUint32(raw[4:], e.count)
+		if len(e.value) <= 4 {
+			copy(raw[8:], e.value)
+			valueAt[i] = 10 + 12*i + 8
+			continue
+		}
+		if len(out)%2 == 1 {
+			out = append(out, 0)
+		}
+		order.PutUint32(raw[8:], uint32(len(out)))
+		valueAt[i] = len(out)
+		out = append(out, e.value...)
+	}
+
+	newOffsets := make([]uint32, len(chunkOffsets))
+	for i, offset := range chunkOffsets {
+		end := int(offset) + int(chunkCounts[i])
+		if end > len(data) || end < int(offset) {
+			return nil, fmt.Errorf("tiff: image data out of range")
+		}
+		if len(out)%2 == 1 {
+			out = append(out, 0)
+		}
+		newOffsets[i] = uint32(len(out))
+		out = append(out, data[offset:end]...)
+	}
+	for i, e := range entries {
+		if e.tag != offsetsTag {
+			continue
+		}
+		for j, offset := range newOffsets {
+			order.PutUint32(out[valueAt[i]+4*j:], offset)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,236 @@
+package tiff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testPage is an uncompressed 8-bit grayscale page
+type testPage struct {
+	width, height int
+	pix           []byte
+}
+
+// testOrder is binary.LittleEndian or binary.BigEndian
+type testOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+func newTestPage(width, height int, fill byte) testPage {
+	pix := make([]byte, width*height)
+	for i := range pix {
+		pix[i] = fill + byte(i)
+	}
+	return testPage{width, height, pix}
+}
+
+// buildTIFF writes the pages with one strip per row, so that pages taller
+// than one row keep their strip offsets outside of the IFD entries
+func buildTIFF(order testOrder, pages ...testPage) []byte {
+	out := []byte("II*\x00")
+	if order == binary.BigEndian {
+		out = []byte("MM\x00*")
+	}
+	out = append(out, 0, 0, 0, 0)
+	next := 4
+	for _, page := range pages {
+		stripAt := len(out)
+		out = append(out, page.pix...)
+		if len(out)%2 == 1 {
+			out = append(out, 0)
+		}
+
+		offsets := make([]byte, 4*page.height)
+		counts := make([]byte, 4*page.height)
+		for row := 0; row < page.height; row++ {
+			order.PutUint32(offsets[4*row:], uint32(stripAt+row*page.width))
+			order.PutUint32(counts[4*row:], uint32(page.width))
+		}
+		short := func(v int) []byte {
+			b := make([]byte, 4)
+			order.PutUint16(b, uint16(v))
+			return b
+		}
+		entries := []entry{
+			{tagImageWidth, typeShort, 1, short(page.width)},
+			{tagImageLength, typeShort, 1, short(page.height)},
+			{258, typeShort, 1, short(8)},
+			{259, typeShort, 1, short(1)},
+			{262, typeShort, 1, short(1)},
+			{tagStripOffsets, typeLong, uint32(page.height), offsets},
+			{277, typeShort, 1, short(1)},
+			{278, typeShort, 1, short(1)},
+			{tagStripByteCounts, typeLong, uint32(page.height), counts},
+		}
+
+		// the values too long for their entry go before the IFD
+		valueAt := make([]int, len(entries))
+		for i, e := range entries {
+			if len(e.value) > 4 {
+				valueAt[i] = len(out)
+				out = append(out, e.value...)
+			}
+		}
+		ifdAt := len(out)
+		order.PutUint32(out[next:], uint32(ifdAt))
+		out = order.AppendUint16(out, uint16(len(entries)))
+		for i, e := range entries {
+			out = order.AppendUint16(out, e.tag)
+			out = order.AppendUint16(out, e.typ)
+			out = order.AppendUint32(out, e.count)
+			if len(e.value) > 4 {
+				out = order.AppendUint32(out, uint32(valueAt[i]))
+			} else {
+				out = append(out, e.value...)
+			}
+		}
+		next = len(out)
+		out = append(out, 0, 0, 0, 0)
+	}
+	return out
+}
+
+// stripData joins the image data of the only page of a split file
+func stripData(t *testing.T, data []byte) []byte {
+	t.Helper()
+	order, pages, err := readIFDs(data)
+	if err != nil {
+		t.Fatalf("readIFDs() error = %v", err)
+	}
+	if len(pages) != 1 {
+		t.Fatalf("split file has %d pages, want 1", len(pages))
+	}
+	offsets, _ := pages[0].find(tagStripOffsets)
+	counts, _ := pages[0].find(tagStripByteCounts)
+	var pix []byte
+	sizes := counts.uints(order)
+	for i, offset := range offsets.uints(order) {
+		pix = append(pix, data[offset:offset+sizes[i]]...)
+	}
+	return pix
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIsTIFF(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"little endian", buildTIFF(binary.LittleEndian, newTestPage(2, 2, 0)), true},
+		{"big endian", buildTIFF(binary.BigEndian, newTestPage(2, 2, 0)), true},
+		{"png", []byte("\x89PNG\r\n\x1a\n"), false},
+		{"too short", []byte("II"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTIFF(writeFile(t, "file", tt.data)); got != tt.want {
+				t.Errorf("IsTIFF() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if IsTIFF(filepath.Join(t.TempDir(), "missing.tif")) {
+		t.Error("IsTIFF() of a missing file = true")
+	}
+}
+
+func TestCountPagesAndDecodeConfig(t *testing.T) {
+	looping := buildTIFF(binary.LittleEndian, newTestPage(2, 2, 0))
+	// point the next IFD offset of the only page back at itself
+	copy(looping[len(looping)-4:], looping[4:8])
+
+	tests := []struct {
+		name          string
+		data          []byte
+		pages         int
+		width, height int
+		wantErr       error
+	}{
+		{name: "one page", data: buildTIFF(binary.LittleEndian, newTestPage(3, 2, 0)), pages: 1, width: 3, height: 2},
+		{
+			name:  "three pages",
+			data:  buildTIFF(binary.BigEndian, newTestPage(4, 1, 0), newTestPage(2, 5, 0), newTestPage(1, 1, 0)),
+			pages: 3, width: 4, height: 1,
+		},
+		{name: "not a tiff", data: []byte("GIF89a.."), wantErr: ErrNotTIFF},
+		{name: "looping chain", data: looping},
+		{name: "truncated", data: buildTIFF(binary.LittleEndian, newTestPage(3, 3, 0))[:30]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages, err := CountPages(bytes.NewReader(tt.data))
+			config, configErr := DecodeConfig(bytes.NewReader(tt.data))
+			if tt.pages == 0 {
+				if err == nil || configErr == nil {
+					t.Fatalf("CountPages() error = %v, DecodeConfig() error = %v, want errors", err, configErr)
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("CountPages() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || configErr != nil {
+				t.Fatalf("CountPages() error = %v, DecodeConfig() error = %v", err, configErr)
+			}
+			if pages != tt.pages {
+				t.Errorf("CountPages() = %d, want %d", pages, tt.pages)
+			}
+			if config.Width != tt.width || config.Height != tt.height {
+				t.Errorf("DecodeConfig() = %dx%d, want %dx%d", config.Width, config.Height, tt.width, tt.height)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	for _, order := range []testOrder{binary.LittleEndian, binary.BigEndian} {
+		pages := []testPage{newTestPage(5, 1, 10), newTestPage(3, 4, 100), newTestPage(7, 2, 200)}
+		path := writeFile(t, "scan.tif", buildTIFF(order, pages...))
+		dir := filepath.Join(t.TempDir(), "pages")
+
+		paths, err := Split(path, dir, "job")
+		if err != nil {
+			t.Fatalf("Split() error = %v", err)
+		}
+		if len(paths) != len(pages) {
+			t.Fatalf("Split() wrote %d pages, want %d", len(paths), len(pages))
+		}
+		for i, pagePath := range paths {
+			if want := filepath.Join(dir, fmt.Sprintf("job_page_%d.tif", i+1)); pagePath != want {
+				t.Errorf("page %d path = %s, want %s", i+1, pagePath, want)
+			}
+			data, err := os.ReadFile(pagePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			config, err := DecodeConfig(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("page %d: DecodeConfig() error = %v", i+1, err)
+			}
+			if config.Width != pages[i].width || config.Height != pages[i].height {
+				t.Errorf("page %d size = %dx%d, want %dx%d", i+1, config.Width, config.Height, pages[i].width, pages[i].height)
+			}
+			if pix := stripData(t, data); !bytes.Equal(pix, pages[i].pix) {
+				t.Errorf("page %d image data = %v, want %v", i+1, pix, pages[i].pix)
+			}
+		}
+	}
+
+	if _, err := Split(writeFile(t, "page.png", []byte("\x89PNG\r\n\x1a\n")), t.TempDir(), "job"); !errors.Is(err, ErrNotTIFF) {
+		t.Errorf("Split() of a PNG error = %v, want %v", err, ErrNotTIFF)
+	}
+}
@@ -0,0 +1,177 @@
+package translation
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// ErrCircuitOpen is returned without calling the provider while the breaker is open
+var ErrCircuitOpen = errors.New("translation provider unavailable: circuit open")
+
+// BreakerStatus is a snapshot of a CircuitBreaker, suitable for /health
+type BreakerStatus struct {
+	Backend             string    `json:"backend"`
+	State               string    `json:"state"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	OpenedAt            time.Time `json:"opened_at,omitempty"`
+	LastError           string    `json:"last_error,omitempty"`
+}
+
+// CircuitBreaker wraps a backend and fails fast once FailureThreshold
+// consecutive calls have failed. After Cooldown one trial call is let through
+// (half-open); its outcome closes or re-opens the circuit.
+type CircuitBreaker struct {
+	Backend          Translator
+	FailureThreshold int
+	Cooldown         time.Duration
+
+	mu sync.Mutex
+	// onStateChange, if set, is called after every transition
+	onStateChange func(BreakerStatus)
+	state         string
+	failures      int
+	openedAt      time.Time
+	lastErr       string
+	trial         bool
+}
+
+func NewCircuitBreaker(backend Translator, failureThreshold int, cooldown time.Duration) *CircuitBreaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	return &CircuitBreaker{
+		Backend:          backend,
+		FailureThreshold: failureThreshold,
+		Cooldown:         cooldown,
+		state:            BreakerClosed,
+	}
+}
+
+// SetOnStateChange sets the function called, in a goroutine of its own,
+// after every transition
+func (b *CircuitBreaker) SetOnStateChange(onStateChange func(BreakerStatus)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onStateChange = onStateChange
+}
+
+func (b *CircuitBreaker) Name() string {
+	return b.Backend.Name()
+}
+
+func (b *CircuitBreaker) Translate(text, sourceLang, targetLang string) (string, error) {
+	if !b.allow() {
+		return "", ErrCircuitOpen
+	}
+
+	result, err := b.Backend.Translate(text, sourceLang, targetLang)
+	if IsRateLimited(err) {
+		// the provider was not called, nothing to learn about it
+		b.release()
+		return "", err
+	}
+	// a permanent error says nothing about the provider being down
+	if err != nil && !IsPermanent(err) {
+		b.onFailure(err)
+		return "", err
+	}
+	b.onSuccess()
+	return result, err
+}
+
+// Status returns the current state of the breaker
+func (b *CircuitBreaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// report an expired open circuit as half-open, that is what the next call sees
+	state := b.state
+	if state == BreakerOpen && time.Since(b.openedAt) >= b.Cooldown {
+		state = BreakerHalfOpen
+	}
+	return b.statusLocked(state)
+}
+
+func (b *CircuitBreaker) statusLocked(state string) BreakerStatus {
+	status := BreakerStatus{
+		Backend:             b.Backend.Name(),
+		State:               state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastErr,
+	}
+	if state != BreakerClosed {
+		status.OpenedAt = b.openedAt
+	}
+	return status
+}
+
+func (b *CircuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.Cooldown {
+			return false
+		}
+		b.transitionLocked(BreakerHalfOpen)
+		b.trial = true
+		return true
+	case BreakerHalfOpen:
+		// only one trial call at a time
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+// release frees the half-open trial slot without deciding the state
+func (b *CircuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+func (b *CircuitBreaker) onSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.trial = false
+	b.lastErr = ""
+	if b.state != BreakerClosed {
+		b.transitionLocked(BreakerClosed)
+	}
+}
+
+func (b *CircuitBreaker) onFailure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.lastErr = err.Error()
+	if b.state == BreakerHalfOpen || b.failures >= b.FailureThreshold {
+		b.trial = false
+		b.openedAt = time.Now()
+		b.transitionLocked(BreakerOpen)
+	}
+}
+
+func (b *CircuitBreaker) transitionLocked(state string) {
+	b.state = state
+	if b.onStateChange != nil {
+		status := b.statusLocked(state)
+		go b.onStateChange(status)
+	}
+}
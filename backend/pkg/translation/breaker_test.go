@@ -0,0 +1,106 @@
+package translation
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptedTranslator fails while failing is set
+type scriptedTranslator struct {
+	mu      sync.Mutex
+	failing error
+	calls   int
+}
+
+func (s *scriptedTranslator) Name() string {
+	return "scripted"
+}
+
+func (s *scriptedTranslator) Translate(text, sourceLang, targetLang string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.failing != nil {
+		return "", s.failing
+	}
+	return text, nil
+}
+
+func (s *scriptedTranslator) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failing = err
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	backend := &scriptedTranslator{}
+	breaker := NewCircuitBreaker(backend, 2, 20*time.Millisecond)
+	states := make(chan string, 10)
+	breaker.SetOnStateChange(func(status BreakerStatus) {
+		states <- status.State
+	})
+
+	steps := []struct {
+		name    string
+		failing error
+		wait    time.Duration
+		wantErr error
+		state   string
+	}{
+		{name: "closed", state: BreakerClosed},
+		{name: "permanent errors do not count", failing: permanent(errors.New("bad language")), state: BreakerClosed},
+		{name: "first failure", failing: errors.New("timeout"), state: BreakerClosed},
+		{name: "threshold reached", failing: errors.New("timeout"), state: BreakerOpen},
+		{name: "fails fast", failing: errors.New("timeout"), wantErr: ErrCircuitOpen, state: BreakerOpen},
+		{name: "failed trial reopens", failing: errors.New("timeout"), wait: 30 * time.Millisecond, state: BreakerOpen},
+		{name: "trial closes", wait: 30 * time.Millisecond, state: BreakerClosed},
+	}
+	for _, step := range steps {
+		time.Sleep(step.wait)
+		backend.fail(step.failing)
+		calls := backend.calls
+		_, err := breaker.Translate("hello", "en", "vi")
+		if step.wantErr != nil && !errors.Is(err, step.wantErr) {
+			t.Errorf("%s: error = %v, want %v", step.name, err, step.wantErr)
+		}
+		if step.wantErr != nil && backend.calls != calls {
+			t.Errorf("%s: the provider was called with the circuit open", step.name)
+		}
+		if state := breaker.Status().State; state != step.state {
+			t.Errorf("%s: state = %s, want %s", step.name, state, step.state)
+		}
+	}
+
+	// open, half-open, open, half-open, closed; the callbacks run in
+	// goroutines of their own, so only their number is checked
+	for i := 0; i < 5; i++ {
+		select {
+		case <-states:
+		case <-time.After(time.Second):
+			t.Fatalf("got %d state changes, want 5", i)
+		}
+	}
+}
+
+func TestCircuitBreakerSetOnStateChangeConcurrently(t *testing.T) {
+	backend := &scriptedTranslator{failing: errors.New("timeout")}
+	breaker := NewCircuitBreaker(backend, 1, time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				breaker.Translate("hello", "en", "vi")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			breaker.SetOnStateChange(func(BreakerStatus) {})
+		}()
+	}
+	wg.Wait()
+}
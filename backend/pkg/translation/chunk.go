@@ -0,0 +1,227 @@
+package translation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	DefaultChunkSize   = 1500
+	DefaultConcurrency = 1
+)
+
+var (
+	paragraphBreak = regexp.MustCompile(`\n[ \t]*\n\s*`)
+	sentenceEnd    = regexp.MustCompile(`[.!?…。！？]+["'”’)\]]*\s+`)
+	wordBreak      = regexp.MustCompile(`\s+`)
+)
+
+// Chunk is a piece of text small enough for a single provider call.
+// Separator is the original whitespace that followed the chunk, it is
+// appended unchanged after the translated text on reassembly.
+type Chunk struct {
+	Text      string
+	Separator string
+}
+
+// ChunkOptions controls how TranslateChunked splits and schedules the work
+type ChunkOptions struct {
+	// MaxChars is the upper bound of characters (runes) sent in one call
+	MaxChars int
+	// Concurrency is the number of chunks translated at the same time
+	Concurrency int
+}
+
+// piece is a span of text followed by the whitespace that separated it
+// from the next span
+type piece struct {
+	text string
+	sep  string
+}
+
+// SplitChunks splits text into chunks of at most maxChars runes. Paragraph
+// breaks always end a chunk; inside a paragraph sentences are packed together,
+// falling back to words and finally to raw runes for oversized sentences.
+// The leading whitespace of text is returned as a chunk with empty Text.
+func SplitChunks(text string, maxChars int) []Chunk {
+	if maxChars <= 0 {
+		maxChars = DefaultChunkSize
+	}
+
+	var chunks []Chunk
+	trimmed := strings.TrimLeft(text, " \t\r\n")
+	if leading := text[:len(text)-len(trimmed)]; leading != "" {
+		chunks = append(chunks, Chunk{Separator: leading})
+	}
+
+	for _, paragraph := range splitKeepingSeparators(trimmed, paragraphBreak) {
+		sentences := splitKeepingSeparators(paragraph.text, sentenceEnd)
+		packed := pack(sentences, maxChars, func(p piece) []piece {
+			return splitKeepingSeparators(p.text+p.sep, wordBreak)
+		})
+		if len(packed) == 0 {
+			chunks = append(chunks, Chunk{Separator: paragraph.sep})
+			continue
+		}
+		packed[len(packed)-1].Separator += paragraph.sep
+		chunks = append(chunks, packed...)
+	}
+	return chunks
+}
+
+// splitKeepingSeparators cuts text at every match of pattern, the matched
+// text is kept as the separator of the piece before it. Separators matched
+// by sentenceEnd keep their punctuation in the piece text.
+func splitKeepingSeparators(text string, pattern *regexp.Regexp) []piece {
+	var pieces []piece
+	start := 0
+	for _, loc := range pattern.FindAllStringIndex(text, -1) {
+		match := text[loc[0]:loc[1]]
+		body := strings.TrimRight(match, " \t\r\n")
+		pieces = append(pieces, piece{
+			text: text[start:loc[0]] + body,
+			sep:  match[len(body):],
+		})
+		start = loc[1]
+	}
+	if start < len(text) {
+		rest := text[start:]
+		body := strings.TrimRight(rest, " \t\r\n")
+		pieces = append(pieces, piece{text: body, sep: rest[len(body):]})
+	}
+	return pieces
+}
+
+// pack greedily joins pieces into chunks of at most maxChars runes. A piece
+// that is too long on its own is broken down with split, and if that does not
+// help it is cut at rune boundaries.
+func pack(pieces []piece, maxChars int, split func(piece) []piece) []Chunk {
+	var chunks []Chunk
+	var current strings.Builder
+	var currentSep string
+	currentLen := 0
+
+	flush := func() {
+		if currentLen == 0 && current.Len() == 0 {
+			return
+		}
+		chunks = append(chunks, Chunk{Text: current.String(), Separator: currentSep})
+		current.Reset()
+		currentSep = ""
+		currentLen = 0
+	}
+
+	for _, p := range pieces {
+		length := utf8.RuneCountInString(p.text)
+		if length > maxChars {
+			flush()
+			if split != nil {
+				if smaller := split(p); len(smaller) > 1 {
+					chunks = append(chunks, pack(smaller, maxChars, nil)...)
+					continue
+				}
+			}
+			chunks = append(chunks, cutRunes(p, maxChars)...)
+			continue
+		}
+
+		sepLength := utf8.RuneCountInString(currentSep)
+		if currentLen > 0 && currentLen+sepLength+length > maxChars {
+			flush()
+		}
+		if currentLen > 0 {
+			current.WriteString(currentSep)
+			currentLen += sepLength
+		}
+		current.WriteString(p.text)
+		currentLen += length
+		currentSep = p.sep
+	}
+	flush()
+	return chunks
+}
+
+func cutRunes(p piece, maxChars int) []Chunk {
+	var chunks []Chunk
+	runes := []rune(p.text)
+	for start := 0; start < len(runes); start += maxChars {
+		end := start + maxChars
+		if end > len(runes) {
+			end = len(runes)
+		}
+		chunks = append(chunks, Chunk{Text: string(runes[start:end])})
+	}
+	if len(chunks) > 0 {
+		chunks[len(chunks)-1].Separator = p.sep
+	}
+	return chunks
+}
+
+// TranslateChunked splits text with SplitChunks, translates the chunks with
+// at most opts.Concurrency calls in flight and joins the results in the
+// original order with the original separators.
+func TranslateChunked(translator Translator, text, sourceLang, targetLang string, opts ChunkOptions) (string, error) {
+	results, err := TranslateChunkedAll(translator, []string{text}, sourceLang, targetLang, opts)
+	if err != nil {
+		return "", err
+	}
+	return results[0], nil
+}
+
+// TranslateChunkedAll is TranslateChunked for several texts at once, e.g. the
+// paragraphs of a Document, sharing the opts.Concurrency calls in flight
+func TranslateChunkedAll(translator Translator, texts []string, sourceLang, targetLang string, opts ChunkOptions) ([]string, error) {
+	type indexedChunk struct {
+		text int
+		Chunk
+	}
+	var chunks []indexedChunk
+	for t, text := range texts {
+		for _, chunk := range SplitChunks(text, opts.MaxChars) {
+			chunks = append(chunks, indexedChunk{t, chunk})
+		}
+	}
+	results := make([]string, len(chunks))
+	errs := make([]error, len(chunks))
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i, chunk := range chunks {
+		if strings.TrimSpace(chunk.Text) == "" {
+			results[i] = chunk.Text
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, chunk Chunk) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i], errs[i] = translator.Translate(chunk.Text, sourceLang, targetLang)
+		}(i, chunk.Chunk)
+	}
+	wg.Wait()
+
+	out := make([]strings.Builder, len(texts))
+	for i, chunk := range chunks {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("chunk %d of %d: %w", i+1, len(chunks), errs[i])
+		}
+		out[chunk.text].WriteString(results[i])
+		out[chunk.text].WriteString(chunk.Separator)
+	}
+
+	translated := make([]string, len(texts))
+	for t := range out {
+		translated[t] = out[t].String()
+	}
+	return translated, nil
+}
@@ -0,0 +1,136 @@
+package translation
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSplitChunks(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxChars int
+		want     []Chunk
+	}{
+		{
+			name:     "fits",
+			text:     "One sentence. Another one.",
+			maxChars: 100,
+			want:     []Chunk{{Text: "One sentence. Another one."}},
+		},
+		{
+			name:     "paragraphs",
+			text:     "First paragraph.\n\nSecond paragraph.\n",
+			maxChars: 100,
+			want:     []Chunk{{Text: "First paragraph.", Separator: "\n\n"}, {Text: "Second paragraph.", Separator: "\n"}},
+		},
+		{
+			name:     "sentences",
+			text:     "One sentence. Another one! A third?",
+			maxChars: 20,
+			want:     []Chunk{{Text: "One sentence.", Separator: " "}, {Text: "Another one!", Separator: " "}, {Text: "A third?"}},
+		},
+		{
+			name:     "words",
+			text:     "a long sentence without an end",
+			maxChars: 10,
+			want:     []Chunk{{Text: "a long", Separator: " "}, {Text: "sentence", Separator: " "}, {Text: "without an", Separator: " "}, {Text: "end"}},
+		},
+		{
+			name:     "runes",
+			text:     "xinchàothếgiới",
+			maxChars: 5,
+			want:     []Chunk{{Text: "xinch"}, {Text: "àothế"}, {Text: "giới"}},
+		},
+		{
+			name:     "leading whitespace",
+			text:     "\n  Indented.",
+			maxChars: 100,
+			want:     []Chunk{{Separator: "\n  "}, {Text: "Indented."}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitChunks(tt.text, tt.maxChars); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitChunks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// upperTranslator upper-cases the text and records the calls
+type upperTranslator struct {
+	mu       sync.Mutex
+	calls    []string
+	inFlight int
+	peak     int
+	fail     string
+}
+
+func (u *upperTranslator) Name() string {
+	return "upper"
+}
+
+func (u *upperTranslator) Translate(text, sourceLang, targetLang string) (string, error) {
+	u.mu.Lock()
+	u.calls = append(u.calls, text)
+	u.inFlight++
+	u.peak = max(u.peak, u.inFlight)
+	u.mu.Unlock()
+	defer func() {
+		u.mu.Lock()
+		u.inFlight--
+		u.mu.Unlock()
+	}()
+
+	if u.fail != "" && strings.Contains(text, u.fail) {
+		return "", errors.New("provider failed")
+	}
+	return strings.ToUpper(text), nil
+}
+
+func TestTranslateChunked(t *testing.T) {
+	text := "First paragraph. It has two sentences.\n\n\nSecond paragraph.\n"
+	translator := &upperTranslator{}
+	got, err := TranslateChunked(translator, text, "en", "vi", ChunkOptions{MaxChars: 20, Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "FIRST PARAGRAPH. IT HAS TWO SENTENCES.\n\n\nSECOND PARAGRAPH.\n"; got != want {
+		t.Errorf("TranslateChunked() = %q, want %q", got, want)
+	}
+	slices.Sort(translator.calls)
+	if want := []string{"First paragraph.", "It has two", "Second paragraph.", "sentences."}; !slices.Equal(translator.calls, want) {
+		t.Errorf("calls = %q, want %q", translator.calls, want)
+	}
+	if translator.peak > 2 {
+		t.Errorf("%d calls in flight, want at most 2", translator.peak)
+	}
+}
+
+func TestTranslateChunkedAll(t *testing.T) {
+	translator := &upperTranslator{}
+	got, err := TranslateChunkedAll(translator, []string{"One.", "", "  ", "Two. Three."}, "en", "vi", ChunkOptions{MaxChars: 5, Concurrency: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ONE.", "", "  ", "TWO. THREE."}; !slices.Equal(got, want) {
+		t.Errorf("TranslateChunkedAll() = %q, want %q", got, want)
+	}
+	// blank texts are not sent, a word longer than a chunk is cut
+	if want := []string{"One.", "Two.", "Three", "."}; !slices.Equal(translator.calls, want) {
+		t.Errorf("calls = %q, want %q", translator.calls, want)
+	}
+}
+
+func TestTranslateChunkedError(t *testing.T) {
+	translator := &upperTranslator{fail: "Second"}
+	_, err := TranslateChunked(translator, "First.\n\nSecond.", "en", "vi", ChunkOptions{MaxChars: 100})
+	if err == nil || !strings.Contains(err.Error(), "chunk 2 of 2") {
+		t.Errorf("TranslateChunked() error = %v, want the failed chunk", err)
+	}
+}
@@ -0,0 +1,83 @@
+package translation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// DictionaryTranslator translates word by word using a local dictionary file.
+// Words (or whole language pairs) missing from the dictionary are returned
+// unchanged, so with no dictionary it behaves as an identity translator.
+type DictionaryTranslator struct {
+	// Entries maps "source-target" (e.g. "en-vi") to a lowercase word table
+	Entries map[string]map[string]string
+}
+
+// NewDictionaryTranslator loads a JSON file of the form
+// {"en-vi": {"hello": "xin chào"}}. An empty path gives an identity translator.
+func NewDictionaryTranslator(path string) (*DictionaryTranslator, error) {
+	translator := &DictionaryTranslator{Entries: map[string]map[string]string{}}
+	if path == "" {
+		return translator, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dictionary file: %w", err)
+	}
+
+	var raw map[string]map[string]string
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse dictionary file: %w", err)
+	}
+
+	for pair, words := range raw {
+		table := make(map[string]string, len(words))
+		for word, translated := range words {
+			table[strings.ToLower(word)] = translated
+		}
+		translator.Entries[strings.ToLower(pair)] = table
+	}
+	return translator, nil
+}
+
+func (d *DictionaryTranslator) Name() string {
+	return BackendDictionary
+}
+
+func (d *DictionaryTranslator) Translate(text, sourceLang, targetLang string) (string, error) {
+	table, ok := d.Entries[strings.ToLower(sourceLang+"-"+targetLang)]
+	if !ok || len(table) == 0 {
+		return text, nil
+	}
+
+	var out strings.Builder
+	var word []rune
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		original := string(word)
+		if translated, found := table[strings.ToLower(original)]; found {
+			out.WriteString(translated)
+		} else {
+			out.WriteString(original)
+		}
+		word = word[:0]
+	}
+
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' {
+			word = append(word, r)
+			continue
+		}
+		flush()
+		out.WriteRune(r)
+	}
+	flush()
+
+	return out.String(), nil
+}
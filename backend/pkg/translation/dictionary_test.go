@@ -0,0 +1,49 @@
+package translation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDictionaryTranslator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dictionary.json")
+	err := os.WriteFile(path, []byte(`{"EN-VI": {"Hello": "xin chào", "world": "thế giới", "don't": "đừng"}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	translator, err := NewDictionaryTranslator(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		text, source, target string
+		want                 string
+	}{
+		{"Hello, world!", "en", "vi", "xin chào, thế giới!"},
+		{"HELLO world 42", "en", "vi", "xin chào thế giới 42"},
+		{"don't panic", "en", "vi", "đừng panic"},
+		{"Hello, world!", "en", "fr", "Hello, world!"},
+		{"", "en", "vi", ""},
+	}
+	for _, tt := range tests {
+		got, err := translator.Translate(tt.text, tt.source, tt.target)
+		if err != nil {
+			t.Errorf("Translate(%q) error = %v", tt.text, err)
+		}
+		if got != tt.want {
+			t.Errorf("Translate(%q, %s, %s) = %q, want %q", tt.text, tt.source, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestDictionaryTranslatorInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dictionary.json")
+	if err := os.WriteFile(path, []byte(`["not", "a", "dictionary"]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewDictionaryTranslator(path); err == nil {
+		t.Error("NewDictionaryTranslator() of a JSON list succeeded")
+	}
+}
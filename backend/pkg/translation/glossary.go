@@ -0,0 +1,134 @@
+package translation
+
+import (
+	"backend/models"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const maxGlossaryTerms = 5000
+
+// ValidateGlossary checks a glossary submitted through the API and
+// normalizes its language codes
+func ValidateGlossary(glossary *models.Glossary) error {
+	if len(glossary.Terms) == 0 && len(glossary.DoNotTranslate) == 0 {
+		return fmt.Errorf("glossary must contain terms or do_not_translate entries")
+	}
+	if len(glossary.Terms)+len(glossary.DoNotTranslate) > maxGlossaryTerms {
+		return fmt.Errorf("glossary exceeds %d entries", maxGlossaryTerms)
+	}
+	for term, translated := range glossary.Terms {
+		if strings.TrimSpace(term) == "" || strings.TrimSpace(translated) == "" {
+			return fmt.Errorf("glossary terms and translations must not be empty")
+		}
+	}
+	for _, term := range glossary.DoNotTranslate {
+		if strings.TrimSpace(term) == "" {
+			return fmt.Errorf("do_not_translate entries must not be empty")
+		}
+	}
+
+	glossary.SourceLang = strings.ToLower(strings.TrimSpace(glossary.SourceLang))
+	glossary.TargetLang = strings.ToLower(strings.TrimSpace(glossary.TargetLang))
+	if _, ok := SupportedLanguages[glossary.SourceLang]; glossary.SourceLang != "" && !ok {
+		return fmt.Errorf("unsupported glossary source language: %s", glossary.SourceLang)
+	}
+	if _, ok := SupportedLanguages[glossary.TargetLang]; glossary.TargetLang != "" && !ok {
+		return fmt.Errorf("unsupported glossary target language: %s", glossary.TargetLang)
+	}
+	return nil
+}
+
+// GlossaryMatches reports whether a glossary can be used for the language
+// pair. Empty glossary languages match anything, and so does an "auto" source.
+func GlossaryMatches(glossary *models.Glossary, sourceLang, targetLang string) bool {
+	if glossary.SourceLang != "" && sourceLang != AutoDetect && glossary.SourceLang != sourceLang {
+		return false
+	}
+	return glossary.TargetLang == "" || glossary.TargetLang == targetLang
+}
+
+// glossaryMatcher finds the terms of a glossary in the segments of a job
+type glossaryMatcher struct {
+	// required maps a lowercased term to its translation, empty for
+	// do-not-translate terms
+	required map[string]string
+	pattern  *regexp.Regexp
+}
+
+// newGlossaryMatcher compiles the terms of glossary into one pattern, once
+// per job; a glossary may hold maxGlossaryTerms terms. It returns nil if
+// there is nothing to match.
+func newGlossaryMatcher(glossary *models.Glossary) *glossaryMatcher {
+	if glossary == nil {
+		return nil
+	}
+
+	required := make(map[string]string, len(glossary.Terms)+len(glossary.DoNotTranslate))
+	for _, term := range glossary.DoNotTranslate {
+		required[strings.ToLower(term)] = ""
+	}
+	for term, translated := range glossary.Terms {
+		required[strings.ToLower(term)] = translated
+	}
+	if len(required) == 0 {
+		return nil
+	}
+
+	terms := make([]string, 0, len(required))
+	for term := range required {
+		terms = append(terms, regexp.QuoteMeta(term))
+	}
+	sort.Slice(terms, func(i, j int) bool { return len(terms[i]) > len(terms[j]) })
+	return &glossaryMatcher{required: required, pattern: regexp.MustCompile(`(?i)` + strings.Join(terms, "|"))}
+}
+
+// protect replaces every glossary term in text with a placeholder. Terms
+// are matched case-insensitively on whole words, longest term first. The
+// placeholder restores to the required translation, or to the original
+// spelling for do-not-translate terms.
+func (g *glossaryMatcher) protect(text string, ph *placeholders) string {
+	if g == nil {
+		return text
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, loc := range g.pattern.FindAllStringIndex(text, -1) {
+		if !isWordBoundary(text, loc[0], loc[1]) {
+			continue
+		}
+		match := text[loc[0]:loc[1]]
+		replacement := g.required[strings.ToLower(match)]
+		if replacement == "" {
+			replacement = match
+		}
+		out.WriteString(text[last:loc[0]])
+		out.WriteString(ph.add(replacement))
+		last = loc[1]
+	}
+	out.WriteString(text[last:])
+	return out.String()
+}
+
+// isWordBoundary reports whether text[start:end] is not glued to letters or
+// digits on either side
+func isWordBoundary(text string, start, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(text[:start])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	if end < len(text) {
+		r, _ := utf8.DecodeRuneInString(text[end:])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
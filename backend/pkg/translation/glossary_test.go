@@ -0,0 +1,122 @@
+package translation
+
+import (
+	"backend/models"
+	"strings"
+	"testing"
+)
+
+func TestGlossaryMatcher(t *testing.T) {
+	glossary := &models.Glossary{
+		Terms:          map[string]string{"invoice": "hóa đơn", "invoice number": "số hóa đơn"},
+		DoNotTranslate: []string{"Acme"},
+	}
+	tests := []struct {
+		name      string
+		text      string
+		protected string
+		restored  string
+	}{
+		{"term", "Pay the invoice.", "Pay the ⟦0⟧.", "Pay the hóa đơn."},
+		{"case insensitive", "INVOICE due", "⟦0⟧ due", "hóa đơn due"},
+		{"longest first", "Invoice number 42", "⟦0⟧ 42", "số hóa đơn 42"},
+		{"do not translate keeps spelling", "ACME and Acme", "⟦0⟧ and ⟦1⟧", "ACME and Acme"},
+		{"whole words only", "Invoices of Acmeco", "Invoices of Acmeco", "Invoices of Acmeco"},
+		{"nothing to protect", "Hello", "Hello", "Hello"},
+	}
+	matcher := newGlossaryMatcher(glossary)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ph placeholders
+			protected := matcher.protect(tt.text, &ph)
+			if protected != tt.protected {
+				t.Errorf("protect(%q) = %q, want %q", tt.text, protected, tt.protected)
+			}
+			restored, err := ph.restore(protected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if restored != tt.restored {
+				t.Errorf("restore(%q) = %q, want %q", protected, restored, tt.restored)
+			}
+		})
+	}
+}
+
+func TestGlossaryMatcherEmpty(t *testing.T) {
+	for _, glossary := range []*models.Glossary{nil, {}} {
+		matcher := newGlossaryMatcher(glossary)
+		if matcher != nil {
+			t.Errorf("newGlossaryMatcher(%+v) = %+v, want nil", glossary, matcher)
+		}
+		var ph placeholders
+		if got := matcher.protect("Pay the invoice", &ph); got != "Pay the invoice" || ph.count() != 0 {
+			t.Errorf("protect() without a glossary = %q, want the text unchanged", got)
+		}
+	}
+}
+
+func TestGlossaryMatches(t *testing.T) {
+	tests := []struct {
+		glossarySource, glossaryTarget string
+		source, target                 string
+		want                           bool
+	}{
+		{"", "", "en", "vi", true},
+		{"en", "vi", "en", "vi", true},
+		{"en", "vi", AutoDetect, "vi", true},
+		{"en", "vi", "fr", "vi", false},
+		{"en", "vi", "en", "de", false},
+		{"", "vi", "fr", "vi", true},
+	}
+	for _, tt := range tests {
+		glossary := &models.Glossary{SourceLang: tt.glossarySource, TargetLang: tt.glossaryTarget}
+		if got := GlossaryMatches(glossary, tt.source, tt.target); got != tt.want {
+			t.Errorf("GlossaryMatches(%s-%s, %s-%s) = %v, want %v",
+				tt.glossarySource, tt.glossaryTarget, tt.source, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestValidateGlossary(t *testing.T) {
+	tests := []struct {
+		name     string
+		glossary models.Glossary
+		wantErr  string
+	}{
+		{"valid", models.Glossary{SourceLang: " EN ", Terms: map[string]string{"invoice": "hóa đơn"}}, ""},
+		{"empty", models.Glossary{}, "must contain terms"},
+		{"empty term", models.Glossary{Terms: map[string]string{"invoice": " "}}, "must not be empty"},
+		{"empty do not translate", models.Glossary{DoNotTranslate: []string{""}}, "must not be empty"},
+		{"unsupported language", models.Glossary{TargetLang: "xx", DoNotTranslate: []string{"Acme"}}, "unsupported glossary target language"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGlossary(&tt.glossary)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateGlossary() error = %v", err)
+				}
+				if tt.glossary.SourceLang != "en" {
+					t.Errorf("SourceLang = %q, want it normalized to en", tt.glossary.SourceLang)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateGlossary() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRestoreLostPlaceholder(t *testing.T) {
+	var ph placeholders
+	ph.add("Acme")
+	ph.add("42")
+	if _, err := ph.restore("⟦ 0 ⟧ only"); err == nil || !strings.Contains(err.Error(), "⟦1⟧") {
+		t.Errorf("restore() error = %v, want the missing ⟦1⟧", err)
+	}
+	if _, err := ph.restore("⟦0⟧ ⟦1⟧ ⟦7⟧"); err == nil || !strings.Contains(err.Error(), "⟦7⟧") {
+		t.Errorf("restore() error = %v, want the unknown ⟦7⟧", err)
+	}
+}
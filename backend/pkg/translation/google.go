@@ -0,0 +1,42 @@
+package translation
+
+import (
+	gt "github.com/bas24/googletranslatefree"
+)
+
+// googleBadRequest is the error of the scraper for an error 400 page, the
+// only one caused by the request; the others are network, throttling or
+// server problems, which come back as pages that are not JSON
+const googleBadRequest = "Error 400 (Bad Request)"
+
+// GoogleTranslator uses the free translate.googleapis.com endpoint
+type GoogleTranslator struct{}
+
+func NewGoogleTranslator() *GoogleTranslator {
+	return &GoogleTranslator{}
+}
+
+func (g *GoogleTranslator) Name() string {
+	return BackendGoogle
+}
+
+func (g *GoogleTranslator) Translate(text, sourceLang, targetLang string) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+	// you can use "auto" for source language
+	// so, translator will detect language
+	result, err := gt.Translate(text, sourceLang, targetLang)
+	if err != nil {
+		return "", classifyGoogleError(err)
+	}
+	return result, nil
+}
+
+// classifyGoogleError marks a bad request permanent, it fails again on retry
+func classifyGoogleError(err error) error {
+	if err.Error() == googleBadRequest {
+		return permanent(err)
+	}
+	return err
+}
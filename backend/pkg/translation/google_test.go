@@ -0,0 +1,30 @@
+package translation
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClassifyGoogleError(t *testing.T) {
+	tests := []struct {
+		err       string
+		permanent bool
+	}{
+		{"Error 400 (Bad Request)", true},
+		{"Error getting translate.googleapis.com", false},
+		{"Error unmarshaling data", false},
+		{"No translated data in responce", false},
+	}
+	for _, tt := range tests {
+		err := classifyGoogleError(errors.New(tt.err))
+		if IsPermanent(err) != tt.permanent || err.Error() != tt.err {
+			t.Errorf("classifyGoogleError(%q) = %v, permanent %v, want permanent %v", tt.err, err, IsPermanent(err), tt.permanent)
+		}
+	}
+}
+
+func TestGoogleTranslatorEmptyText(t *testing.T) {
+	if result, err := NewGoogleTranslator().Translate("", "en", "vi"); result != "" || err != nil {
+		t.Errorf(`Translate("") = %q, %v`, result, err)
+	}
+}
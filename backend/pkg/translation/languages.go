@@ -0,0 +1,124 @@
+package translation
+
+import (
+	"backend/pkg/langdetect"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	AutoDetect        = "auto"
+	DefaultSourceLang = AutoDetect
+	DefaultTargetLang = "en"
+
+	// MinDetectionConfidence is the confidence below which the offline
+	// detection is only reported and "auto" is passed on to the backend
+	MinDetectionConfidence = 0.5
+
+	// MaxTargetLanguages caps the fan-out of a single upload
+	MaxTargetLanguages = 10
+)
+
+// SupportedLanguages lists the language codes accepted on upload,
+// restricted to those available on both Google and LibreTranslate
+var SupportedLanguages = map[string]string{
+	"ar": "Arabic",
+	"cs": "Czech",
+	"da": "Danish",
+	"de": "German",
+	"el": "Greek",
+	"en": "English",
+	"es": "Spanish",
+	"fi": "Finnish",
+	"fr": "French",
+	"he": "Hebrew",
+	"hi": "Hindi",
+	"hu": "Hungarian",
+	"id": "Indonesian",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"nl": "Dutch",
+	"pl": "Polish",
+	"pt": "Portuguese",
+	"ro": "Romanian",
+	"ru": "Russian",
+	"sv": "Swedish",
+	"th": "Thai",
+	"tr": "Turkish",
+	"uk": "Ukrainian",
+	"vi": "Vietnamese",
+	"zh": "Chinese",
+}
+
+// NormalizeLanguages lowercases the pair and fills in defaults for empty values
+func NormalizeLanguages(sourceLang, targetLang string) (string, string) {
+	sourceLang = strings.ToLower(strings.TrimSpace(sourceLang))
+	targetLang = strings.ToLower(strings.TrimSpace(targetLang))
+	if sourceLang == "" {
+		sourceLang = DefaultSourceLang
+	}
+	if targetLang == "" {
+		targetLang = DefaultTargetLang
+	}
+	return sourceLang, targetLang
+}
+
+// NormalizeTargetLanguages splits comma separated values, lowercases and
+// deduplicates them in order, defaulting to DefaultTargetLang
+func NormalizeTargetLanguages(values []string) []string {
+	var langs []string
+	seen := map[string]bool{}
+	for _, value := range values {
+		for _, lang := range strings.Split(value, ",") {
+			lang = strings.ToLower(strings.TrimSpace(lang))
+			if lang == "" || seen[lang] {
+				continue
+			}
+			seen[lang] = true
+			langs = append(langs, lang)
+		}
+	}
+	if len(langs) == 0 {
+		langs = []string{DefaultTargetLang}
+	}
+	return langs
+}
+
+// ValidateLanguages checks the pair against SupportedLanguages.
+// The source may be "auto", the target may not.
+func ValidateLanguages(sourceLang, targetLang string) error {
+	if _, ok := SupportedLanguages[sourceLang]; !ok && sourceLang != AutoDetect {
+		return fmt.Errorf("unsupported source language: %s", sourceLang)
+	}
+	if _, ok := SupportedLanguages[targetLang]; !ok {
+		return fmt.Errorf("unsupported target language: %s", targetLang)
+	}
+	return nil
+}
+
+// LanguageCodes returns the supported language codes in sorted order
+func LanguageCodes() []string {
+	codes := make([]string, 0, len(SupportedLanguages))
+	for code := range SupportedLanguages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// ResolveSourceLang replaces "auto" with the language detected offline in
+// text. It returns the source language to translate from and the detection
+// result, which is empty when no detection was needed.
+func ResolveSourceLang(text, sourceLang string) (string, langdetect.Result) {
+	if sourceLang != AutoDetect {
+		return sourceLang, langdetect.Result{}
+	}
+
+	detected := langdetect.Detect(text)
+	if _, ok := SupportedLanguages[detected.Lang]; !ok || detected.Confidence < MinDetectionConfidence {
+		return AutoDetect, detected
+	}
+	return detected.Lang, detected
+}
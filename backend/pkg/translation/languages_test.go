@@ -0,0 +1,23 @@
+package translation
+
+import "testing"
+
+func TestResolveSourceLang(t *testing.T) {
+	tests := []struct {
+		text, sourceLang string
+		want             string
+		wantDetected     string
+	}{
+		{"Die Rechnung ist innerhalb von dreißig Tagen zu bezahlen.", AutoDetect, "de", "de"},
+		{"Die Rechnung ist innerhalb von dreißig Tagen zu bezahlen.", "fr", "fr", ""},
+		// too short to trust, the backend detects it
+		{"Hi", AutoDetect, AutoDetect, ""},
+		{"Invoice 請求書 paid", AutoDetect, AutoDetect, "en"},
+	}
+	for _, tt := range tests {
+		got, detected := ResolveSourceLang(tt.text, tt.sourceLang)
+		if got != tt.want || detected.Lang != tt.wantDetected {
+			t.Errorf("ResolveSourceLang(%q, %q) = %q, %+v, want %q detected as %q", tt.text, tt.sourceLang, got, detected, tt.want, tt.wantDetected)
+		}
+	}
+}
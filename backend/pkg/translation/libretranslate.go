@@ -0,0 +1,96 @@
+package translation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LibreTranslator talks to a LibreTranslate compatible HTTP API
+type LibreTranslator struct {
+	BaseURL string
+	APIKey  string
+	Client  *http.Client
+}
+
+type libreRequest struct {
+	Q      string `json:"q"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Format string `json:"format"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+type libreResponse struct {
+	TranslatedText string `json:"translatedText"`
+	Error          string `json:"error"`
+}
+
+func NewLibreTranslator(baseURL, apiKey string, timeout time.Duration) (*LibreTranslator, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("libretranslate backend requires LIBRETRANSLATE_URL")
+	}
+	return &LibreTranslator{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		APIKey:  apiKey,
+		Client:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (l *LibreTranslator) Name() string {
+	return BackendLibreTranslate
+}
+
+func (l *LibreTranslator) Translate(text, sourceLang, targetLang string) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	body, err := json.Marshal(libreRequest{
+		Q:      text,
+		Source: sourceLang,
+		Target: targetLang,
+		Format: "text",
+		APIKey: l.APIKey,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", l.BaseURL+"/translate", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := l.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to call libretranslate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var result libreResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("failed to decode response (status %d): %w", resp.StatusCode, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("libretranslate returned status %d: %s", resp.StatusCode, result.Error)
+		// rate limiting and server errors are worth retrying, other client errors are not
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return "", permanent(err)
+		}
+		return "", err
+	}
+
+	return result.TranslatedText, nil
+}
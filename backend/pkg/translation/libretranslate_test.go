@@ -0,0 +1,102 @@
+package translation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLibreTranslator(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		response  string
+		want      string
+		wantErr   bool
+		permanent bool
+	}{
+		{name: "translated", status: http.StatusOK, response: `{"translatedText":"xin chào"}`, want: "xin chào"},
+		{name: "bad request", status: http.StatusBadRequest, response: `{"error":"vi is not supported"}`, wantErr: true, permanent: true},
+		{name: "forbidden", status: http.StatusForbidden, response: `{"error":"invalid API key"}`, wantErr: true, permanent: true},
+		{name: "rate limited", status: http.StatusTooManyRequests, response: `{"error":"slow down"}`, wantErr: true},
+		{name: "server error", status: http.StatusInternalServerError, response: `{"error":"boom"}`, wantErr: true},
+		{name: "not json", status: http.StatusBadGateway, response: `<html>bad gateway</html>`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got libreRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/translate" {
+					t.Errorf("request = %s %s, want POST /translate", r.Method, r.URL.Path)
+				}
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			translator, err := NewLibreTranslator(server.URL+"/", "secret", time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := translator.Translate("hello", "en", "vi")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Translate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if IsPermanent(err) != tt.permanent {
+				t.Errorf("IsPermanent(%v) = %v, want %v", err, !tt.permanent, tt.permanent)
+			}
+			if result != tt.want {
+				t.Errorf("Translate() = %q, want %q", result, tt.want)
+			}
+			want := libreRequest{Q: "hello", Source: "en", Target: "vi", Format: "text", APIKey: "secret"}
+			if got != want {
+				t.Errorf("request = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestLibreTranslatorEmptyText(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("empty text must not call the API")
+	}))
+	defer server.Close()
+
+	translator, err := NewLibreTranslator(server.URL, "", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, err := translator.Translate("", "en", "vi"); result != "" || err != nil {
+		t.Errorf(`Translate("") = %q, %v`, result, err)
+	}
+}
+
+func TestNewTranslator(t *testing.T) {
+	tests := []struct {
+		cfg     Config
+		want    string
+		wantErr bool
+	}{
+		{cfg: Config{}, want: BackendGoogle},
+		{cfg: Config{Backend: "LibreTranslate", LibreTranslateURL: "http://localhost:5000"}, want: BackendLibreTranslate},
+		{cfg: Config{Backend: BackendLibreTranslate}, wantErr: true},
+		{cfg: Config{Backend: BackendDictionary}, want: BackendDictionary},
+		{cfg: Config{Backend: BackendDictionary, DictionaryPath: "testdata/missing.json"}, wantErr: true},
+		{cfg: Config{Backend: "deepl"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		translator, err := NewTranslator(tt.cfg)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewTranslator(%+v) error = %v, wantErr %v", tt.cfg, err, tt.wantErr)
+			continue
+		}
+		if err == nil && translator.Name() != tt.want {
+			t.Errorf("NewTranslator(%+v) = %s, want %s", tt.cfg, translator.Name(), tt.want)
+		}
+	}
+}
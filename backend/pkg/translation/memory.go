@@ -0,0 +1,44 @@
+package translation
+
+import (
+	"log"
+)
+
+// SegmentStore is a translation memory, see redis_utils.TranslationMemory
+type SegmentStore interface {
+	Lookup(segment, sourceLang, targetLang, backend string) (string, bool)
+	Store(segment, sourceLang, targetLang, backend, translated string) error
+}
+
+// MemoryTranslator consults a SegmentStore before calling the wrapped backend
+// and stores every fresh translation. Used with TranslateChunked it is
+// consulted once per paragraph (or per chunk for long paragraphs).
+type MemoryTranslator struct {
+	Backend Translator
+	Memory  SegmentStore
+}
+
+func NewMemoryTranslator(backend Translator, memory SegmentStore) *MemoryTranslator {
+	return &MemoryTranslator{Backend: backend, Memory: memory}
+}
+
+func (m *MemoryTranslator) Name() string {
+	return m.Backend.Name()
+}
+
+func (m *MemoryTranslator) Translate(text, sourceLang, targetLang string) (string, error) {
+	if translated, ok := m.Memory.Lookup(text, sourceLang, targetLang, m.Backend.Name()); ok {
+		return translated, nil
+	}
+
+	translated, err := m.Backend.Translate(text, sourceLang, targetLang)
+	if err != nil {
+		return "", err
+	}
+
+	// a failed write only costs a provider call next time
+	if err := m.Memory.Store(text, sourceLang, targetLang, m.Backend.Name(), translated); err != nil {
+		log.Printf("Translation memory: %v", err)
+	}
+	return translated, nil
+}
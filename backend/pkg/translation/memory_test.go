@@ -0,0 +1,62 @@
+package translation
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// mapStore is a SegmentStore in a map, keyed by segment and languages
+type mapStore struct {
+	entries map[string]string
+	failing error
+}
+
+func (m *mapStore) Lookup(segment, sourceLang, targetLang, backend string) (string, bool) {
+	translated, ok := m.entries[backend+":"+sourceLang+":"+targetLang+":"+segment]
+	return translated, ok
+}
+
+func (m *mapStore) Store(segment, sourceLang, targetLang, backend, translated string) error {
+	if m.failing != nil {
+		return m.failing
+	}
+	m.entries[backend+":"+sourceLang+":"+targetLang+":"+segment] = translated
+	return nil
+}
+
+func TestMemoryTranslator(t *testing.T) {
+	backend := &upperTranslator{fail: "broken"}
+	store := &mapStore{entries: map[string]string{"upper:en:vi:Hello": "Xin chào"}}
+	translator := NewMemoryTranslator(backend, store)
+
+	tests := []struct {
+		text, targetLang string
+		want             string
+		wantErr          bool
+	}{
+		{text: "Hello", targetLang: "vi", want: "Xin chào"},
+		{text: "Hello", targetLang: "fr", want: "HELLO"},
+		{text: "Hello", targetLang: "fr", want: "HELLO"},
+		{text: "broken", targetLang: "vi", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := translator.Translate(tt.text, "en", tt.targetLang)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Translate(%q, %s) = %q, %v, want %q", tt.text, tt.targetLang, got, err, tt.want)
+		}
+	}
+	// the French one was translated once and remembered, the failure was not
+	if want := []string{"Hello", "broken"}; !slices.Equal(backend.calls, want) {
+		t.Errorf("backend calls = %q, want %q", backend.calls, want)
+	}
+	if _, ok := store.entries["upper:en:vi:broken"]; ok {
+		t.Error("a failed translation was stored")
+	}
+
+	// a memory that can not be written still translates
+	store.failing = errors.New("read only")
+	if got, err := translator.Translate("Bye", "en", "vi"); err != nil || got != "BYE" {
+		t.Errorf("Translate() with a failing store = %q, %v, want BYE", got, err)
+	}
+}
@@ -0,0 +1,71 @@
+package translation
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// placeholderPattern tolerates the spaces some providers insert around
+// punctuation they do not know
+var placeholderPattern = regexp.MustCompile(`⟦\s*(\d+)\s*⟧`)
+
+// placeholders swaps protected spans of text for opaque tokens before the
+// provider call and puts the replacements back afterwards
+type placeholders struct {
+	replacements []string
+}
+
+func placeholderToken(index int) string {
+	return fmt.Sprintf("⟦%d⟧", index)
+}
+
+// add registers the text a token must be restored to and returns the token
+func (p *placeholders) add(replacement string) string {
+	p.replacements = append(p.replacements, replacement)
+	return placeholderToken(len(p.replacements) - 1)
+}
+
+// escapePlaceholders swaps the text of the source that looks like a token,
+// e.g. a literal ⟦1⟧, for a token restoring to it, so that it can not be
+// taken for the token of another span
+func escapePlaceholders(text string, ph *placeholders) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, ph.add)
+}
+
+func (p *placeholders) count() int {
+	return len(p.replacements)
+}
+
+// restore replaces every token in text with its replacement and fails if a
+// token was lost or invented by the provider
+func (p *placeholders) restore(text string) (string, error) {
+	if p.count() == 0 {
+		return text, nil
+	}
+
+	seen := make([]bool, len(p.replacements))
+	var unknown []string
+	restored := placeholderPattern.ReplaceAllStringFunc(text, func(token string) string {
+		index, err := strconv.Atoi(placeholderPattern.FindStringSubmatch(token)[1])
+		if err != nil || index >= len(p.replacements) {
+			unknown = append(unknown, token)
+			return token
+		}
+		seen[index] = true
+		return p.replacements[index]
+	})
+
+	var missing []string
+	for index, ok := range seen {
+		if !ok {
+			missing = append(missing, placeholderToken(index))
+		}
+	}
+	if len(missing) > 0 || len(unknown) > 0 {
+		return restored, fmt.Errorf("placeholders lost in translation: missing [%s], unknown [%s]",
+			strings.Join(missing, " "), strings.Join(unknown, " "))
+	}
+	return restored, nil
+}
@@ -0,0 +1,85 @@
+package translation
+
+import (
+	"errors"
+	"log"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrRateLimited is returned when the shared provider budget stays exhausted
+// for longer than RateLimit.MaxWait. The job should be requeued, not failed.
+var ErrRateLimited = errors.New("translation provider budget exhausted")
+
+// IsRateLimited reports whether err was caused by the shared rate limit
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// RateLimit is the outbound budget of one backend, shared by every worker.
+// A zero rate means no limit.
+type RateLimit struct {
+	RequestsPerSec float64
+	CharsPerSec    float64
+	// Burst is how many seconds of budget may be spent at once
+	Burst time.Duration
+	// MaxWait bounds how long a call waits for budget before giving up
+	MaxWait time.Duration
+}
+
+// Enabled reports whether the limit restricts anything
+func (r RateLimit) Enabled() bool {
+	return r.RequestsPerSec > 0 || r.CharsPerSec > 0
+}
+
+// RateLimiter hands out the provider budget, see redis_utils.RateLimiter
+type RateLimiter interface {
+	// Reserve takes one request and chars characters from the budget of
+	// backend. If the budget is short nothing is taken and the time until
+	// it refills is returned.
+	Reserve(backend string, chars int) (time.Duration, error)
+}
+
+// RateLimitedTranslator waits for budget before every call of the wrapped
+// backend. Without a Limiter calls go straight through.
+type RateLimitedTranslator struct {
+	Backend Translator
+	Limiter RateLimiter
+	MaxWait time.Duration
+}
+
+func NewRateLimitedTranslator(backend Translator, maxWait time.Duration) *RateLimitedTranslator {
+	return &RateLimitedTranslator{Backend: backend, MaxWait: maxWait}
+}
+
+func (r *RateLimitedTranslator) Name() string {
+	return r.Backend.Name()
+}
+
+func (r *RateLimitedTranslator) Translate(text, sourceLang, targetLang string) (string, error) {
+	if r.Limiter != nil && text != "" {
+		if err := r.wait(utf8.RuneCountInString(text)); err != nil {
+			return "", err
+		}
+	}
+	return r.Backend.Translate(text, sourceLang, targetLang)
+}
+
+func (r *RateLimitedTranslator) wait(chars int) error {
+	deadline := time.Now().Add(r.MaxWait)
+	for {
+		delay, err := r.Limiter.Reserve(r.Backend.Name(), chars)
+		if err != nil {
+			// an unreachable limiter must not stop translation
+			log.Printf("Rate limiter: %v", err)
+			return nil
+		}
+		if delay <= 0 {
+			return nil
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return ErrRateLimited
+		}
+		time.Sleep(delay)
+	}
+}
@@ -0,0 +1,65 @@
+package translation
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// scriptedLimiter returns its delays in turn, then lets every call through
+type scriptedLimiter struct {
+	delays []time.Duration
+	err    error
+	chars  []int
+}
+
+func (s *scriptedLimiter) Reserve(backend string, chars int) (time.Duration, error) {
+	s.chars = append(s.chars, chars)
+	if s.err != nil {
+		return 0, s.err
+	}
+	if len(s.delays) == 0 {
+		return 0, nil
+	}
+	delay := s.delays[0]
+	s.delays = s.delays[1:]
+	return delay, nil
+}
+
+func TestRateLimitedTranslator(t *testing.T) {
+	tests := []struct {
+		name    string
+		limiter *scriptedLimiter
+		wantErr error
+		calls   int
+	}{
+		{name: "budget left", limiter: &scriptedLimiter{}, calls: 1},
+		{name: "waits for budget", limiter: &scriptedLimiter{delays: []time.Duration{10 * time.Millisecond, 10 * time.Millisecond}}, calls: 3},
+		{name: "waits too long", limiter: &scriptedLimiter{delays: []time.Duration{time.Hour}}, wantErr: ErrRateLimited, calls: 1},
+		{name: "limiter down", limiter: &scriptedLimiter{err: errors.New("connection refused")}, calls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &upperTranslator{}
+			translator := NewRateLimitedTranslator(backend, time.Second)
+			translator.Limiter = tt.limiter
+
+			got, err := translator.Translate("xin chào", "vi", "en")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Translate() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(tt.limiter.chars) != tt.calls || tt.limiter.chars[0] != 8 {
+				t.Errorf("Reserve() calls = %v, want %d of 8 characters", tt.limiter.chars, tt.calls)
+			}
+			if tt.wantErr != nil {
+				if len(backend.calls) != 0 {
+					t.Error("the backend was called without budget")
+				}
+				return
+			}
+			if got != "XIN CHÀO" {
+				t.Errorf("Translate() = %q, want %q", got, "XIN CHÀO")
+			}
+		})
+	}
+}
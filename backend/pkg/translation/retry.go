@@ -0,0 +1,89 @@
+package translation
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// PermanentError marks a provider failure that retrying will not fix,
+// e.g. an unsupported language pair or a rejected API key
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+func permanent(err error) error {
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent reports whether err should not be retried
+func IsPermanent(err error) bool {
+	var permanentErr *PermanentError
+	return errors.As(err, &permanentErr) || errors.Is(err, ErrCircuitOpen)
+}
+
+// RetryTranslator retries transient failures of the wrapped backend with
+// exponential backoff and full jitter
+type RetryTranslator struct {
+	Backend     Translator
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+func NewRetryTranslator(backend Translator, maxAttempts int, baseDelay, maxDelay time.Duration) *RetryTranslator {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &RetryTranslator{
+		Backend:     backend,
+		MaxAttempts: maxAttempts,
+		BaseDelay:   baseDelay,
+		MaxDelay:    maxDelay,
+	}
+}
+
+func (r *RetryTranslator) Name() string {
+	return r.Backend.Name()
+}
+
+func (r *RetryTranslator) Translate(text, sourceLang, targetLang string) (string, error) {
+	attempt := 1
+	for {
+		result, err := r.Backend.Translate(text, sourceLang, targetLang)
+		if err == nil {
+			return result, nil
+		}
+		// running out of budget is left to the caller, retrying would only wait longer
+		if IsPermanent(err) || IsRateLimited(err) || attempt >= r.MaxAttempts {
+			return "", fmt.Errorf("translation failed after %d attempt(s): %w", attempt, err)
+		}
+
+		delay := r.backoff(attempt)
+		log.Printf("Translation attempt %d/%d failed: %v, retrying in %v", attempt, r.MaxAttempts, err, delay)
+		time.Sleep(delay)
+		attempt++
+	}
+}
+
+// backoff returns a random delay in [0, min(MaxDelay, BaseDelay * 2^(attempt-1))]
+func (r *RetryTranslator) backoff(attempt int) time.Duration {
+	ceiling := r.BaseDelay << (attempt - 1)
+	if ceiling <= 0 || ceiling > r.MaxDelay {
+		ceiling = r.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
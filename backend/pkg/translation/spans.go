@@ -0,0 +1,155 @@
+package translation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	SpanURL    = "url"
+	SpanEmail  = "email"
+	SpanNumber = "number"
+	SpanCode   = "code"
+)
+
+// DefaultSpanKinds are the built-in span patterns enabled when
+// TRANSLATION_PROTECT_SPANS is not set
+const DefaultSpanKinds = SpanURL + "," + SpanEmail + "," + SpanNumber + "," + SpanCode
+
+// SpanPattern matches text that must reach the output exactly as in the
+// source, e.g. URLs or part numbers
+type SpanPattern struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// builtinSpanPatterns are listed by priority, a URL wins over the email
+// address or number inside it
+var builtinSpanPatterns = []SpanPattern{
+	{SpanURL, regexp.MustCompile(`(?i)\b(?:(?:https?|ftp)://|www\.)[^\s<>"'()\[\]]*[^\s<>"'()\[\].,;:!?]`)},
+	{SpanEmail, regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b`)},
+	// letters glued to digits (AB-1234, x86_64, v2.4.1) and snake_case identifiers
+	{SpanCode, regexp.MustCompile(`\b(?:[A-Za-z0-9]*(?:[A-Za-z][-_./]?[0-9]|[0-9][-_./]?[A-Za-z])[A-Za-z0-9]*(?:[-_./][A-Za-z0-9]+)*|[A-Za-z][A-Za-z0-9]*(?:_[A-Za-z0-9]+)+)\b`)},
+	// 42, 3.14, 1,000,000, 12:30, 2024-01-31, 50%
+	{SpanNumber, regexp.MustCompile(`[-+]?\b[0-9]+(?:[.,:/-][0-9]+)*\b%?`)},
+}
+
+var spanPatterns = DefaultSpanPatterns()
+
+// DefaultSpanPatterns returns the built-in patterns of DefaultSpanKinds
+func DefaultSpanPatterns() []SpanPattern {
+	patterns, _ := LoadSpanPatterns(DefaultSpanKinds, "")
+	return patterns
+}
+
+// LoadSpanPatterns returns the built-in patterns named in the comma separated
+// kinds ("none" disables them) followed by the custom patterns of the JSON
+// file at path, of the form {"ticket": "JIRA-[0-9]+"}
+func LoadSpanPatterns(kinds, path string) ([]SpanPattern, error) {
+	var patterns []SpanPattern
+
+	enabled := map[string]bool{}
+	for _, kind := range strings.Split(kinds, ",") {
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		if kind == "" || kind == "none" {
+			continue
+		}
+		enabled[kind] = true
+	}
+	for _, builtin := range builtinSpanPatterns {
+		if enabled[builtin.Name] {
+			patterns = append(patterns, builtin)
+			delete(enabled, builtin.Name)
+		}
+	}
+	for kind := range enabled {
+		return nil, fmt.Errorf("unknown span kind: %s", kind)
+	}
+
+	if path == "" {
+		return patterns, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read span patterns file: %w", err)
+	}
+	var raw map[string]string
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse span patterns file: %w", err)
+	}
+
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pattern, err := regexp.Compile(raw[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid span pattern %s: %w", name, err)
+		}
+		patterns = append(patterns, SpanPattern{Name: name, Pattern: pattern})
+	}
+	return patterns, nil
+}
+
+// SetSpanPatterns replaces the patterns protected by TranslateFilter
+func SetSpanPatterns(patterns []SpanPattern) {
+	spanPatterns = patterns
+}
+
+// protectSpans replaces every span matched by patterns with a placeholder
+// that restores to the original text. Overlapping matches are resolved by
+// position, then length, then pattern order. Placeholders already in text
+// (glossary terms) are left alone.
+func protectSpans(text string, patterns []SpanPattern, ph *placeholders) string {
+	if len(patterns) == 0 {
+		return text
+	}
+
+	type span struct {
+		start, end, priority int
+	}
+	var spans []span
+	for _, token := range placeholderPattern.FindAllStringIndex(text, -1) {
+		spans = append(spans, span{token[0], token[1], -1})
+	}
+	for priority, pattern := range patterns {
+		for _, loc := range pattern.Pattern.FindAllStringIndex(text, -1) {
+			if loc[0] < loc[1] {
+				spans = append(spans, span{loc[0], loc[1], priority})
+			}
+		}
+	}
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		if spans[i].end-spans[i].start != spans[j].end-spans[j].start {
+			return spans[i].end-spans[i].start > spans[j].end-spans[j].start
+		}
+		return spans[i].priority < spans[j].priority
+	})
+
+	var out strings.Builder
+	last := 0
+	for _, s := range spans {
+		if s.start < last {
+			continue
+		}
+		out.WriteString(text[last:s.start])
+		if s.priority < 0 {
+			out.WriteString(text[s.start:s.end])
+		} else {
+			out.WriteString(ph.add(text[s.start:s.end]))
+		}
+		last = s.end
+	}
+	out.WriteString(text[last:])
+	return out.String()
+}
@@ -0,0 +1,199 @@
+package translation
+
+import (
+	"backend/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestProtectSpans(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		kinds string
+		spans []string
+	}{
+		{"url", "See https://example.com/a?b=1. Thanks", DefaultSpanKinds, []string{"https://example.com/a?b=1"}},
+		{"url wins over email", "Mail www.example.com/u@x.org now", DefaultSpanKinds, []string{"www.example.com/u@x.org"}},
+		{"email", "Write to jane.doe@example.co.uk today", DefaultSpanKinds, []string{"jane.doe@example.co.uk"}},
+		{"numbers", "Pay 1,250.00 by 2024-01-31 at 12:30, 50% now", DefaultSpanKinds, []string{"1,250.00", "2024-01-31", "12:30", "50%"}},
+		{"codes", "Order AB-1234 for x86_64 and max_value", DefaultSpanKinds, []string{"AB-1234", "x86_64", "max_value"}},
+		{"only numbers", "Room 12 of x86 costs -42", SpanNumber, []string{"12", "-42"}},
+		{"none", "Call 555 or visit www.example.com", "none", nil},
+		{"plain text", "Nothing to keep here.", DefaultSpanKinds, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patterns, err := LoadSpanPatterns(tt.kinds, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ph placeholders
+			protected := protectSpans(tt.text, patterns, &ph)
+			if strings.Join(ph.replacements, "|") != strings.Join(tt.spans, "|") {
+				t.Errorf("protected spans = %q, want %q", ph.replacements, tt.spans)
+			}
+			restored, err := ph.restore(protected)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if restored != tt.text {
+				t.Errorf("restore(%q) = %q, want %q", protected, restored, tt.text)
+			}
+		})
+	}
+}
+
+func TestBuiltinSpanPatterns(t *testing.T) {
+	tests := []struct {
+		kind string
+		text string
+		want []string
+	}{
+		{SpanURL, "ftp://files.example.org/a.zip, then WWW.Example.com.", []string{"ftp://files.example.org/a.zip", "WWW.Example.com"}},
+		{SpanURL, "(see http://example.com/page)", []string{"http://example.com/page"}},
+		{SpanURL, "example.com and mailto:a@b.io", nil},
+		{SpanEmail, "a.b+tag@mail.example.com, c@d", []string{"a.b+tag@mail.example.com"}},
+		{SpanCode, "Use v2.4.1 of AB-1234, not_a_word or x86", []string{"v2.4.1", "AB-1234", "not_a_word", "x86"}},
+		{SpanCode, "plain words and 2024", nil},
+		{SpanNumber, "+3.14 and 1/2, 07-08 or 99% of 1,000,000", []string{"+3.14", "1/2", "07-08", "99%", "1,000,000"}},
+		{SpanNumber, "no digits here", nil},
+	}
+	for _, tt := range tests {
+		patterns, err := LoadSpanPatterns(tt.kind, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := patterns[0].Pattern.FindAllString(tt.text, -1)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s pattern in %q matched %q, want %q", tt.kind, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTranslateSegmentsProtectedSpans(t *testing.T) {
+	previous := defaultTranslator
+	t.Cleanup(func() { SetTranslator(previous) })
+	SetTranslator(&upperTranslator{})
+
+	results, report, err := TranslateSegments([]string{"call 555 or www.example.com", "Acme owes 42"}, Options{
+		SourceLang: "en",
+		TargetLang: "vi",
+		Glossary:   &models.Glossary{DoNotTranslate: []string{"Acme"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"CALL 555 OR www.example.com", "Acme OWES 42"}; strings.Join(results, "|") != strings.Join(want, "|") {
+		t.Errorf("TranslateSegments() = %q, want %q", results, want)
+	}
+	// the glossary term is not a protected span
+	if report.ProtectedSpans != 3 {
+		t.Errorf("ProtectedSpans = %d, want 3", report.ProtectedSpans)
+	}
+}
+
+func TestProtectSpansKeepsGlossaryPlaceholders(t *testing.T) {
+	var ph placeholders
+	text := ph.add("Acme 42") + " costs 42"
+	protected := protectSpans(text, DefaultSpanPatterns(), &ph)
+	if protected != "⟦0⟧ costs ⟦1⟧" {
+		t.Errorf("protectSpans() = %q, want %q", protected, "⟦0⟧ costs ⟦1⟧")
+	}
+}
+
+func TestLoadSpanPatterns(t *testing.T) {
+	dir := t.TempDir()
+	custom := filepath.Join(dir, "patterns.json")
+	if err := os.WriteFile(custom, []byte(`{"ticket": "JIRA-[0-9]+"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"broken": "[a-"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		kinds, path string
+		want        []string
+		wantErr     bool
+	}{
+		{kinds: DefaultSpanKinds, want: []string{SpanURL, SpanEmail, SpanCode, SpanNumber}},
+		{kinds: " Number , url", want: []string{SpanURL, SpanNumber}},
+		{kinds: "none", path: custom, want: []string{"ticket"}},
+		{kinds: "url,phone", wantErr: true},
+		{kinds: "none", path: invalid, wantErr: true},
+		{kinds: "none", path: filepath.Join(dir, "missing.json"), wantErr: true},
+	}
+	for _, tt := range tests {
+		patterns, err := LoadSpanPatterns(tt.kinds, tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("LoadSpanPatterns(%q, %q) error = %v, wantErr %v", tt.kinds, tt.path, err, tt.wantErr)
+			continue
+		}
+		var names []string
+		for _, pattern := range patterns {
+			names = append(names, pattern.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("LoadSpanPatterns(%q, %q) = %q, want %q", tt.kinds, tt.path, names, tt.want)
+		}
+	}
+}
+
+// droppingTranslator upper-cases the text and loses the tokens of the first
+// drops texts holding any
+type droppingTranslator struct {
+	mu    sync.Mutex
+	drops int
+}
+
+func (d *droppingTranslator) Name() string {
+	return "dropping"
+}
+
+func (d *droppingTranslator) Translate(text, sourceLang, targetLang string) (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.drops > 0 && placeholderPattern.MatchString(text) {
+		d.drops--
+		return placeholderPattern.ReplaceAllString(strings.ToUpper(text), ""), nil
+	}
+	return strings.ToUpper(text), nil
+}
+
+func TestTranslateSegmentsLostPlaceholders(t *testing.T) {
+	previous := defaultTranslator
+	t.Cleanup(func() { SetTranslator(previous) })
+
+	segments := []string{"room 12 is free", "no spans here", "literal ⟦0⟧ stays, like 7"}
+	tests := []struct {
+		name          string
+		drops         int
+		want          []string
+		wantFallbacks int
+	}{
+		{"kept", 0, []string{"ROOM 12 IS FREE", "NO SPANS HERE", "LITERAL ⟦0⟧ STAYS, LIKE 7"}, 0},
+		// the batch loses the tokens of both, their retries keep them
+		{"retried", 2, []string{"ROOM 12 IS FREE", "NO SPANS HERE", "LITERAL ⟦0⟧ STAYS, LIKE 7"}, 0},
+		{"left untranslated", 4, []string{"room 12 is free", "NO SPANS HERE", "literal ⟦0⟧ stays, like 7"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetTranslator(&droppingTranslator{drops: tt.drops})
+			results, report, err := TranslateSegments(segments, Options{SourceLang: "en", TargetLang: "vi"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(results, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("TranslateSegments() = %q, want %q", results, tt.want)
+			}
+			if report.Fallbacks != tt.wantFallbacks || report.ProtectedSpans != 2 {
+				t.Errorf("report = %+v, want %d fallbacks and 2 protected spans", report, tt.wantFallbacks)
+			}
+		})
+	}
+}
@@ -1,14 +1,325 @@
 package translation
 
 import (
-	gt "github.com/bas24/googletranslatefree"
+	"backend/models"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+const (
+	BackendGoogle         = "google"
+	BackendLibreTranslate = "libretranslate"
+	BackendDictionary     = "dictionary"
+)
+
+// Translator translates a piece of text from one language to another
+type Translator interface {
+	// Name identifies the backend, e.g. "google" or "libretranslate"
+	Name() string
+	Translate(text, sourceLang, targetLang string) (string, error)
+}
+
+// Config selects the translation backend and holds its settings
+type Config struct {
+	Backend              string
+	LibreTranslateURL    string
+	LibreTranslateAPIKey string
+	DictionaryPath       string
+	// Timeout bounds the LibreTranslate calls, the Google scraper sets none
+	Timeout time.Duration
+	// ChunkSize and Concurrency control how long texts are split, see TranslateChunked
+	ChunkSize   int
+	Concurrency int
+	// MaxAttempts, RetryBaseDelay and RetryMaxDelay configure RetryTranslator
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// BreakerThreshold and BreakerCooldown configure CircuitBreaker
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	// SpanKinds and SpanPatternsPath select the spans protected from the
+	// provider, see LoadSpanPatterns
+	SpanKinds        string
+	SpanPatternsPath string
+	// RateLimit is the budget of the backend shared by all workers, it takes
+	// effect once a limiter is installed with SetRateLimiter
+	RateLimit RateLimit
+}
+
+var defaultTranslator Translator
+var breaker *CircuitBreaker
+var rateLimited *RateLimitedTranslator
+var chunkOptions = ChunkOptions{MaxChars: DefaultChunkSize, Concurrency: DefaultConcurrency}
+
+// ConfigFromEnv reads the translator configuration from environment variables
+func ConfigFromEnv() Config {
+	backend := strings.ToLower(os.Getenv("TRANSLATION_BACKEND"))
+	if backend == "" {
+		backend = BackendGoogle
+	}
+	return Config{
+		Backend:              backend,
+		LibreTranslateURL:    os.Getenv("LIBRETRANSLATE_URL"),
+		LibreTranslateAPIKey: os.Getenv("LIBRETRANSLATE_API_KEY"),
+		DictionaryPath:       os.Getenv("TRANSLATION_DICTIONARY_PATH"),
+		Timeout:              envDuration("TRANSLATION_TIMEOUT", 30*time.Second),
+		ChunkSize:            envInt("TRANSLATION_CHUNK_SIZE", DefaultChunkSize),
+		Concurrency:          envInt("TRANSLATION_CONCURRENCY", DefaultConcurrency),
+		MaxAttempts:          envInt("TRANSLATION_MAX_ATTEMPTS", 3),
+		RetryBaseDelay:       envDuration("TRANSLATION_RETRY_BASE_DELAY", 500*time.Millisecond),
+		RetryMaxDelay:        envDuration("TRANSLATION_RETRY_MAX_DELAY", 10*time.Second),
+		BreakerThreshold:     envInt("TRANSLATION_BREAKER_THRESHOLD", 5),
+		BreakerCooldown:      envDuration("TRANSLATION_BREAKER_COOLDOWN", 30*time.Second),
+		SpanKinds:            envString("TRANSLATION_PROTECT_SPANS", DefaultSpanKinds),
+		SpanPatternsPath:     os.Getenv("TRANSLATION_SPAN_PATTERNS_PATH"),
+		RateLimit:            rateLimitFromEnv(backend),
+	}
+}
+
+// rateLimitFromEnv reads the budget of backend, a backend specific variable
+// such as TRANSLATION_GOOGLE_REQUESTS_PER_SEC wins over the generic one
+func rateLimitFromEnv(backend string) RateLimit {
+	prefix := "TRANSLATION_" + strings.ToUpper(backend) + "_"
+	return RateLimit{
+		RequestsPerSec: envFloat(prefix+"REQUESTS_PER_SEC", envFloat("TRANSLATION_REQUESTS_PER_SEC", 0)),
+		CharsPerSec:    envFloat(prefix+"CHARS_PER_SEC", envFloat("TRANSLATION_CHARS_PER_SEC", 0)),
+		Burst:          envDuration("TRANSLATION_RATE_LIMIT_BURST", time.Second),
+		MaxWait:        envDuration("TRANSLATION_RATE_LIMIT_MAX_WAIT", 30*time.Second),
+	}
+}
+
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func envFloat(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func envString(key, fallback string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// NewTranslator builds the backend named in the config (google if empty)
+func NewTranslator(cfg Config) (Translator, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "", BackendGoogle:
+		return NewGoogleTranslator(), nil
+	case BackendLibreTranslate:
+		return NewLibreTranslator(cfg.LibreTranslateURL, cfg.LibreTranslateAPIKey, cfg.Timeout)
+	case BackendDictionary:
+		return NewDictionaryTranslator(cfg.DictionaryPath)
+	default:
+		return nil, fmt.Errorf("unknown translation backend: %s", cfg.Backend)
+	}
+}
+
+// Initialize sets up the translator used by TranslateFilter (call only once at startup).
+// The backend is wrapped in a RateLimitedTranslator, a RetryTranslator and a
+// CircuitBreaker, so every retry also waits for budget.
+func Initialize(cfg Config) error {
+	backend, err := NewTranslator(cfg)
+	if err != nil {
+		return err
+	}
+	spanPatterns, err = LoadSpanPatterns(cfg.SpanKinds, cfg.SpanPatternsPath)
+	if err != nil {
+		return err
+	}
+	rateLimited = NewRateLimitedTranslator(backend, cfg.RateLimit.MaxWait)
+	retrying := NewRetryTranslator(rateLimited, cfg.MaxAttempts, cfg.RetryBaseDelay, cfg.RetryMaxDelay)
+	breaker = NewCircuitBreaker(retrying, cfg.BreakerThreshold, cfg.BreakerCooldown)
+	defaultTranslator = breaker
+	if cfg.ChunkSize > 0 {
+		chunkOptions.MaxChars = cfg.ChunkSize
+	}
+	if cfg.Concurrency > 0 {
+		chunkOptions.Concurrency = cfg.Concurrency
+	}
+
+	fmt.Printf("Translator initialized with %s backend\n", backend.Name())
+	return nil
+}
+
+// Breaker returns the circuit breaker set up by Initialize, or nil
+func Breaker() *CircuitBreaker {
+	return breaker
+}
+
+// SetRateLimiter makes the translator set up by Initialize wait for budget
+// from limiter before each provider call
+func SetRateLimiter(limiter RateLimiter) {
+	if rateLimited != nil {
+		rateLimited.Limiter = limiter
+	}
+}
+
+// SetTranslator replaces the translator used by TranslateFilter
+func SetTranslator(translator Translator) {
+	defaultTranslator = translator
+}
+
+// CurrentTranslator returns the translator used by TranslateFilter
+func CurrentTranslator() Translator {
+	if defaultTranslator == nil {
+		defaultTranslator = NewGoogleTranslator()
+	}
+	return defaultTranslator
+}
+
+// Options are the per-job settings of TranslateFilter
+type Options struct {
+	SourceLang string
+	TargetLang string
+	// Glossary terms are protected from the provider and substituted afterwards
+	Glossary *models.Glossary
+}
+
+// Report describes what TranslateWithReport did besides translating
+type Report struct {
+	// ProtectedSpans counts the URLs, emails, numbers and codes kept verbatim
+	ProtectedSpans int
+	// Fallbacks counts the segments left untranslated because the provider
+	// lost or changed their placeholders, even on a second try
+	Fallbacks int
+}
+
+// TranslateFilter translates text with the configured backend, split into
+// chunks that respect the provider size limit and keep paragraph breaks.
+// Empty languages fall back to DefaultSourceLang and DefaultTargetLang.
+func TranslateFilter(text string, opts Options) (string, error) {
+	result, _, err := TranslateWithReport(text, opts)
+	return result, err
+}
+
+// TranslateWithReport is TranslateFilter, also returning a Report
+func TranslateWithReport(text string, opts Options) (string, Report, error) {
+	results, report, err := TranslateSegments([]string{text}, opts)
+	if err != nil {
+		return "", report, err
+	}
+	return results[0], report, nil
+}
+
+// TranslateSegments translates each segment on its own, e.g. the blocks of a
+// models.Document, so that every translation maps back to its segment.
+// Glossary terms and spans are protected per segment. A segment whose
+// placeholders do not come back is translated once more on its own, then
+// left untranslated, see Report.Fallbacks.
+func TranslateSegments(segments []string, opts Options) ([]string, Report, error) {
+	var report Report
+	sourceLang, targetLang := NormalizeLanguages(opts.SourceLang, opts.TargetLang)
+	var glossary *glossaryMatcher
+	if opts.Glossary != nil && GlossaryMatches(opts.Glossary, sourceLang, targetLang) {
+		glossary = newGlossaryMatcher(opts.Glossary)
+	}
+
+	protected := make([]string, len(segments))
+	phs := make([]placeholders, len(segments))
+	for i, text := range segments {
+		text = escapePlaceholders(text, &phs[i])
+		text = glossary.protect(text, &phs[i])
+		// glossary terms go first, they may contain numbers or codes themselves
+		glossaryTerms := phs[i].count()
+		protected[i] = protectSpans(text, spanPatterns, &phs[i])
+		report.ProtectedSpans += phs[i].count() - glossaryTerms
+	}
+
+	// same language: nothing to send, but glossary terms are still enforced
+	results := protected
+	if sourceLang != targetLang {
+		var err error
+		results, err = TranslateChunkedAll(CurrentTranslator(), protected, sourceLang, targetLang, chunkOptions)
+		if err != nil {
+			return nil, report, fmt.Errorf("failed to translate text: %w", err)
+		}
+	}
+
+	restored := make([]string, len(results))
+	for i, result := range results {
+		var err error
+		restored[i], err = phs[i].restore(result)
+		if err == nil {
+			continue
+		}
+
+		// providers drop or rewrite a token now and then, rarely twice
+		result, retryErr := TranslateChunked(CurrentTranslator(), protected[i], sourceLang, targetLang, chunkOptions)
+		if retryErr != nil {
+			return nil, report, fmt.Errorf("failed to translate text: %w", retryErr)
+		}
+		restored[i], err = phs[i].restore(result)
+		if err != nil {
+			log.Printf("Segment %d of %d left untranslated: %v", i+1, len(segments), err)
+			restored[i] = segments[i]
+			report.Fallbacks++
+		}
+	}
+	return restored, report, nil
+}
+
+// TranslateDocument translates every block of doc with TranslateSegments and
+// stores the results in Block.TranslatedText. The cells of a table are
+// translated one by one, its TranslatedText is made of theirs.
+func TranslateDocument(doc *models.Document, opts Options) (Report, error) {
+	blocks := doc.Blocks()
+	var segments []string
+	var results []*string
+	for _, block := range blocks {
+		if block.Table == nil {
+			// a region of interest may hold no text
+			if block.Text == "" {
+				continue
+			}
+			segments = append(segments, block.Text)
+			results = append(results, &block.TranslatedText)
+			continue
+		}
+		for _, row := range block.Table.Rows {
+			for c := range row {
+				if row[c].Text != "" {
+					segments = append(segments, row[c].Text)
+					results = append(results, &row[c].TranslatedText)
+				}
+			}
+		}
+	}
 
-func TranslateFilter(text string) string {
-	// you can use "auto" for source language
-	// so, translator will detect language
-	result, _ := gt.Translate(text, "en", "en")
-	// Output: "Hola, Mundo!"
-    return result
-}
\ No newline at end of file
+	translated, report, err := TranslateSegments(segments, opts)
+	if err != nil {
+		return report, err
+	}
+	for i, result := range results {
+		*result = translated[i]
+	}
+	for _, block := range blocks {
+		if block.Table != nil {
+			block.TranslatedText = block.Table.TranslatedText()
+		}
+	}
+	return report, nil
+}
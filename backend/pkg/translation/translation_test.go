@@ -0,0 +1,25 @@
+package translation
+
+import "testing"
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		backend, want string
+	}{
+		{"", BackendGoogle},
+		{"Google", BackendGoogle},
+		{"LIBRETRANSLATE", BackendLibreTranslate},
+	}
+	for _, tt := range tests {
+		t.Setenv("TRANSLATION_BACKEND", tt.backend)
+		if got := ConfigFromEnv().Backend; got != tt.want {
+			t.Errorf("ConfigFromEnv().Backend with TRANSLATION_BACKEND=%q = %q, want %q", tt.backend, got, tt.want)
+		}
+	}
+
+	t.Setenv("TRANSLATION_BACKEND", "LibreTranslate")
+	t.Setenv("TRANSLATION_LIBRETRANSLATE_REQUESTS_PER_SEC", "4")
+	if got := ConfigFromEnv().RateLimit.RequestsPerSec; got != 4 {
+		t.Errorf("RateLimit.RequestsPerSec = %v, want the libretranslate budget 4", got)
+	}
+}
@@ -2,6 +2,8 @@ package utils
 
 
 import (
+	"backend/pkg/tiff"
+	"errors"
 	"mime/multipart"
 	"path/filepath"
 	"fmt"
@@ -96,3 +98,52 @@ func GenerateHashFromFormFile(fileHeader *multipart.FileHeader) (string, error)
 
 	return fmt.Sprintf("%x", hash.Sum(nil)), nil
 }
+
+// CountPagesFromFormFile returns the number of pages of an uploaded file,
+// 1 unless it is a multi-page TIFF
+func CountPagesFromFormFile(fileHeader *multipart.FileHeader) (int, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	pages, err := tiff.CountPages(file)
+	if errors.Is(err, tiff.ErrNotTIFF) {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid TIFF file: %w", err)
+	}
+	return pages, nil
+}
+
+// IsTIFFFromFormFile reports whether an uploaded file starts with a TIFF
+// header
+func IsTIFFFromFormFile(fileHeader *multipart.FileHeader) (bool, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return false, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	_, err = tiff.CountPages(file)
+	return !errors.Is(err, tiff.ErrNotTIFF), nil
+}
+
+// GenerateJobID derives the job ID from the file hash and the options that
+// change the result, so the same file submitted with different options does
+// not hit the cached result of another job
+func GenerateJobID(fileHash string, options ...string) string {
+	if len(options) == 0 {
+		return fileHash
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(fileHash))
+	for _, option := range options {
+		hash.Write([]byte{0})
+		hash.Write([]byte(option))
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
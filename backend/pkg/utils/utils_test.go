@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"bytes"
+	"mime/multipart"
+	"testing"
+)
+
+// formFile uploads data as the file field of a multipart form
+func formFile(t *testing.T, data []byte) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	writer.Close()
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File["file"][0]
+}
+
+// onePage and twoPages are TIFF files of empty image file directories, one a page
+var (
+	onePage  = []byte("II*\x00\x08\x00\x00\x00" + "\x00\x00" + "\x00\x00\x00\x00")
+	twoPages = []byte("II*\x00\x08\x00\x00\x00" + "\x00\x00" + "\x0e\x00\x00\x00" + "\x00\x00" + "\x00\x00\x00\x00")
+)
+
+func TestFormFilePages(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		wantTIFF  bool
+		wantPages int
+		wantErr   bool
+	}{
+		{name: "png", data: []byte("\x89PNG\r\n\x1a\n"), wantPages: 1},
+		{name: "one page tiff", data: onePage, wantTIFF: true, wantPages: 1},
+		{name: "two page tiff", data: twoPages, wantTIFF: true, wantPages: 2},
+		{name: "broken tiff", data: []byte("MM\x00*\x00\x00\x00\xff"), wantTIFF: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := formFile(t, tt.data)
+			isTIFF, err := IsTIFFFromFormFile(file)
+			if err != nil {
+				t.Fatalf("IsTIFFFromFormFile() error = %v", err)
+			}
+			if isTIFF != tt.wantTIFF {
+				t.Errorf("IsTIFFFromFormFile() = %v, want %v", isTIFF, tt.wantTIFF)
+			}
+
+			pages, err := CountPagesFromFormFile(file)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("CountPagesFromFormFile() = %d, want an error", pages)
+				}
+				return
+			}
+			if err != nil || pages != tt.wantPages {
+				t.Errorf("CountPagesFromFormFile() = %d, %v, want %d", pages, err, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestGenerateJobID(t *testing.T) {
+	if got := GenerateJobID("abc"); got != "abc" {
+		t.Errorf("GenerateJobID() without options = %q, want the file hash", got)
+	}
+	if GenerateJobID("abc", "vi", "en") == GenerateJobID("abc", "vie", "n") {
+		t.Error("GenerateJobID() mixes up the boundaries of the options")
+	}
+	if GenerateJobID("abc", "vi") != GenerateJobID("abc", "vi") {
+		t.Error("GenerateJobID() is not stable")
+	}
+}
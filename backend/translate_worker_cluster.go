@@ -6,8 +6,10 @@ import (
 	"context"
 	"time"
 	"encoding/json"
+	"os"
 	"backend/pkg/translation"
 	"backend/pkg/pdf"
+	"backend/pkg/aws_utils"
 	"backend/models"
 	"github.com/joho/godotenv"
 	"github.com/redis/go-redis/v9"
@@ -23,6 +25,11 @@ var margins = map[string]float64{
 
 var redisClient *redis.ClusterClient
 var redisCtx context.Context
+var translationMemory *redis_utils.TranslationMemory
+
+// how long a throttled job waits in translation-delay-queue before it is
+// back in translation-queue
+const rateLimitRequeueDelay = 5 * time.Second
 
 
 // Update average response time in Redis
@@ -49,6 +56,10 @@ func main() {
 	if err != nil {
 		log.Fatal("Error loading .env file")
 	}
+
+	cfg := translation.ConfigFromEnv()
+	err = translation.Initialize(cfg)
+	rabbitmq_utils.FailOnError(err, "Failed to initialize translator")
 	
 	conn, err := rabbitmq_utils.ConnectRabbitMQ()
 	rabbitmq_utils.FailOnError(err, "Failed to connect to RabbitMQ")
@@ -56,6 +67,24 @@ func main() {
 
 	redisClient, redisCtx = redis_utils.InitRedisCluster(false)
 
+	// share the provider budget with the other translate workers
+	if cfg.RateLimit.Enabled() {
+		translation.SetRateLimiter(redis_utils.NewRateLimiter(redisClient, redisCtx, cfg.RateLimit))
+		log.Printf("Translation rate limit: %.1f requests/s, %.0f chars/s", cfg.RateLimit.RequestsPerSec, cfg.RateLimit.CharsPerSec)
+	}
+
+	if os.Getenv("TRANSLATION_MEMORY") == "yes" {
+		ttl, err := time.ParseDuration(os.Getenv("TRANSLATION_MEMORY_TTL"))
+		if err != nil {
+			ttl = 30 * 24 * time.Hour
+		}
+		translationMemory = redis_utils.NewTranslationMemory(redisClient, redisCtx, ttl)
+		translation.SetTranslator(translation.NewMemoryTranslator(translation.CurrentTranslator(), translationMemory))
+		log.Printf("Translation memory enabled, TTL %v", ttl)
+	}
+
+	go reportBreakerStatus()
+
 	channel, err := conn.Channel()
 	rabbitmq_utils.FailOnError(err, "Failed to open a channel")
 	defer channel.Close()
@@ -70,6 +99,10 @@ func main() {
 	translate_queue, err := rabbitmq_utils.InitQueue(channel, "translation-queue")
 	rabbitmq_utils.FailOnError(err, "Failed to declare a queue")
 
+	// throttled jobs wait there, the worker goes on with the others
+	delay_queue, err := rabbitmq_utils.InitDelayQueue(channel, "translation-delay-queue", translate_queue.Name, rateLimitRequeueDelay)
+	rabbitmq_utils.FailOnError(err, "Failed to declare the delay queue")
+
 	msgs, err := rabbitmq_utils.ConsumeMessage(channel, translate_queue.Name)
 	rabbitmq_utils.FailOnError(err, "Failed to register a consumer")
 
@@ -83,24 +116,70 @@ func main() {
 			err := json.Unmarshal(d.Body, &job)
 			rabbitmq_utils.FailOnError(err, "Failed to unmarshal job")
 
-			_, err = processMessage(&job)
-			if err != nil {
-				log.Printf("Failed to translate: %v", err)
+			// messages queued before per-job languages existed carry empty values
+			job.SourceLang, job.TargetLang = translation.NormalizeLanguages(job.SourceLang, job.TargetLang)
+
+			if job.FailureReason == "" {
+				_, err = processMessage(&job)
+				if translation.IsRateLimited(err) {
+					// not the job's fault: retry it once the budget refilled
+					log.Printf("Job %s (%s) throttled, retrying in %v: %v", job.JobID, job.TargetLang, rateLimitRequeueDelay, err)
+					redisClient.HSet(redisCtx, job.JobID, "status:"+job.TargetLang, "throttled")
+					err = rabbitmq_utils.Delay(channel, delay_queue.Name, d)
+					if err != nil {
+						log.Printf("Job %s (%s) requeued at once: %v", job.JobID, job.TargetLang, err)
+					}
+					continue
+				}
+				if err != nil {
+					log.Printf("Failed to translate to %s: %v", job.TargetLang, err)
+					job.FailureReason = err.Error()
+				}
 			}
 			
 			job.CompletedAt = time.Now()
         	job.ResponseTime = job.CompletedAt.Sub(job.SubmittedAt)
 
-			updateAverageResponseTime(job.ResponseTime)
-
 			data := map[string]interface{}{
 				"response_time": job.ResponseTime.Milliseconds(), // Store as milliseconds
-				"status":        "completed",
+				"source_lang":   job.SourceLang,
+				"detected_lang": job.DetectedLang,
+				"detected_lang_confidence": job.DetectedLangConfidence,
+			}
+			for key, value := range redis_utils.ProtectedSpansFields(job.TargetLang, job.ProtectedSpans, job.TargetLang == job.TargetLanguages()[0]) {
+				data[key] = value
+			}
+			if job.Document != nil {
+				data["orientation"] = job.Orientation
+				data["orientation_detected"] = job.OrientationDetected
+				data["script"] = job.Script
+				data["tables"] = redis_utils.TablesField(job.Document.Tables())
+				if len(job.Regions) > 0 {
+					for key, value := range redis_utils.RegionFields(job.TargetLang, job.Document) {
+						data[key] = value
+					}
+				}
+			}
+			if job.PageConfidences != nil {
+				for key, value := range redis_utils.OCRConfidenceFields(job.OCRConfidence, job.PageConfidences, job.LowConfidence) {
+					data[key] = value
+				}
 			}
 			err = redisClient.HSet(redisCtx, job.JobID, data).Err()
 			rabbitmq_utils.FailOnError(err, "Failed to set response time Redis")
 
-			log.Printf("Total processing time: %v", job.ResponseTime)
+			// the job is done once every target language is
+			status, err := redis_utils.CompleteTargetLang(redisClient, redisCtx, job.JobID, job.TargetLang, job.FailureReason, len(job.TargetLanguages()))
+			rabbitmq_utils.FailOnError(err, "Failed to set status Redis")
+			if status != "" && status != redis_utils.StatusFailed {
+				updateAverageResponseTime(job.ResponseTime)
+			}
+
+			log.Printf("Total processing time (%s): %v", job.TargetLang, job.ResponseTime)
+			if translationMemory != nil {
+				hits, misses := translationMemory.LocalStats()
+				log.Printf("Translation memory: %d hits, %d misses", hits, misses)
+			}
 			d.Ack(false)
 		}
 	}()
@@ -110,16 +189,95 @@ func main() {
 }
 
 
+// reportBreakerStatus publishes the circuit breaker state on every transition
+// and as a heartbeat, so /health on the API servers can show open circuits
+func reportBreakerStatus() {
+	breaker := translation.Breaker()
+	if breaker == nil {
+		return
+	}
+
+	hostname, _ := os.Hostname()
+	workerID := fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	report := func(status translation.BreakerStatus) {
+		err := redis_utils.ReportBreakerStatus(redisClient, redisCtx, workerID, status)
+		if err != nil {
+			log.Printf("Failed to report circuit breaker status: %v", err)
+		}
+	}
+
+	breaker.SetOnStateChange(func(status translation.BreakerStatus) {
+		log.Printf("Translation circuit breaker is now %s", status.State)
+		report(status)
+	})
+	for {
+		report(breaker.Status())
+		time.Sleep(15 * time.Second)
+	}
+}
+
+
 func processMessage(job *models.Job) (string, error) {
-	translatedText := translation.TranslateFilter(job.ExtractedText)
-	job.TranslatedText = translatedText
+	sourceLang, detected := translation.ResolveSourceLang(job.ExtractedText, job.SourceLang)
+	job.DetectedLang, job.DetectedLangConfidence = detected.Lang, detected.Confidence
 
-	var OutFilePath string
+	var glossary *models.Glossary
+	if job.GlossaryID != "" {
+		if job.TenantID == "" {
+			job.TenantID = models.DefaultTenant
+		}
+		var err error
+		glossary, err = redis_utils.GetGlossary(redisClient, redisCtx, job.TenantID, job.GlossaryID)
+		if err != nil {
+			return "", fmt.Errorf("failed to load glossary %s: %w", job.GlossaryID, err)
+		}
+	}
+
+	translateOptions := translation.Options{
+		SourceLang: sourceLang,
+		TargetLang: job.TargetLang,
+		Glossary:   glossary,
+	}
+	var report translation.Report
 	var err error
+	if job.Document != nil {
+		report, err = translation.TranslateDocument(job.Document, translateOptions)
+		if err == nil {
+			job.TranslatedText = job.Document.TranslatedText()
+		}
+	} else {
+		// messages queued before the document model only carry the text
+		job.TranslatedText, report, err = translation.TranslateWithReport(job.ExtractedText, translateOptions)
+	}
+	job.ProtectedSpans = report.ProtectedSpans
+	if err != nil {
+		return "", err
+	}
+
+	pdfOptions := pdf.Options{
+		Layout:       job.OutputLayout,
+		OriginalText: job.ExtractedText,
+		SourceLang:   sourceLang,
+		TargetLang:   job.TargetLang,
+		Document:     job.Document,
+		HighlightBelow: job.HighlightBelow,
+		ImagePath: job.ImagePath,
+	}
+	if job.OutputLayout == pdf.LayoutSearchable || job.OutputLayout == pdf.LayoutSearchableTranslated {
+		// the page image is drawn under the text, fetch it unless this
+		// host has it already
+		if _, statErr := os.Stat(job.ImagePath); statErr != nil && job.ImageDownloadURL != "" {
+			err = aws_utils.DownloadFile(job.ImageDownloadURL, job.ImagePath)
+			if err != nil {
+				return "", fmt.Errorf("failed to download image: %w", err)
+			}
+		}
+	}
+	var OutFilePath string
 	if job.PDFUploadURL != "" {
-		OutFilePath, err = pdf.ExportPDFtoS3(job.TranslatedText, job.JobID, margins, job.PDFUploadURL)
+		OutFilePath, err = pdf.ExportPDFtoS3(job.TranslatedText, job.OutputName(), margins, job.PDFUploadURL, pdfOptions)
 	} else {
-		OutFilePath, err = pdf.ExportPDF(job.TranslatedText, job.JobID, margins)
+		OutFilePath, err = pdf.ExportPDF(job.TranslatedText, job.OutputName(), margins, pdfOptions)
 	}
 
 